@@ -31,18 +31,118 @@ func TestSurfaceAdd(t *testing.T) {
 	}
 }
 
+func TestSurfaceAddReplacesByID(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "a"))
+	s.Add(TextStatic("a", "First"))
+	s.Add(Column("root", "a", "b"))
+	s.Add(TextStatic("b", "Second"))
+
+	if len(s.components) != 3 {
+		t.Fatalf("expected 3 components, got %d", len(s.components))
+	}
+
+	root, ok := s.components[0].(Component)
+	if !ok || root.ID != "root" {
+		t.Fatalf("expected root to keep first position, got %v", s.components[0])
+	}
+	if len(root.Children) != 2 {
+		t.Errorf("expected replaced root with 2 children, got %d", len(root.Children))
+	}
+
+	msg := s.UpdateComponentsMessage()
+	if len(msg.UpdateComponents.Components) != 3 {
+		t.Errorf("expected 3 components in update, got %d", len(msg.UpdateComponents.Components))
+	}
+}
+
+func TestSurfaceReplace(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hello"))
+
+	if !s.Replace(TextStatic("root", "Goodbye")) {
+		t.Error("expected Replace to report an existing component")
+	}
+	c, _ := s.Get("root")
+	if c.(Component).Text != "Goodbye" {
+		t.Errorf("expected replaced text 'Goodbye', got '%s'", c.(Component).Text)
+	}
+
+	if s.Replace(TextStatic("missing", "x")) {
+		t.Error("expected Replace to report a missing component")
+	}
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Replace must not add missing components")
+	}
+}
+
+func TestSurfaceRemove(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "b"))
+	s.Add(TextStatic("a", "A"))
+	s.Add(TextStatic("b", "B"))
+	s.Add(TextStatic("c", "C"))
+
+	if !s.Remove("a") {
+		t.Error("expected Remove to report an existing component")
+	}
+	if s.Remove("a") {
+		t.Error("expected second Remove to report a missing component")
+	}
+	if len(s.components) != 3 {
+		t.Fatalf("expected 3 components, got %d", len(s.components))
+	}
+
+	// Index must stay consistent after removal
+	s.Add(TextStatic("c", "C2"))
+	if len(s.components) != 3 {
+		t.Errorf("expected re-add after Remove to replace, got %d components", len(s.components))
+	}
+	c, ok := s.Get("c")
+	if !ok || c.(Component).Text != "C2" {
+		t.Errorf("expected 'C2', got %v", c)
+	}
+}
+
+func TestSurfaceGet(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hello"))
+
+	c, ok := s.Get("root")
+	if !ok {
+		t.Fatal("expected Get to find root")
+	}
+	if c.(Component).Text != "Hello" {
+		t.Errorf("expected 'Hello', got '%s'", c.(Component).Text)
+	}
+	if _, ok := s.Get("nope"); ok {
+		t.Error("expected Get to miss unknown ID")
+	}
+}
+
+func TestSurfaceAddEmptyIDNotIndexed(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Component{Component: "Text", Text: "one"})
+	s.Add(Component{Component: "Text", Text: "two"})
+
+	if len(s.components) != 2 {
+		t.Errorf("expected components without ID to be appended, got %d", len(s.components))
+	}
+}
+
 func TestSurfaceSetData(t *testing.T) {
 	s := NewSurface("test")
 	s.SetData("/user/name", "Alice")
 	s.SetData("/items", []string{"a", "b", "c"})
 
-	if s.data["/user/name"] != "Alice" {
-		t.Errorf("expected 'Alice', got '%v'", s.data["/user/name"])
+	if name, _ := s.Data().Get("/user/name"); name != "Alice" {
+		t.Errorf("expected 'Alice', got '%v'", name)
 	}
 
-	items, ok := s.data["/items"].([]string)
+	value, _ := s.Data().Get("/items")
+	items, ok := value.([]string)
 	if !ok || len(items) != 3 {
-		t.Errorf("expected 3 items, got '%v'", s.data["/items"])
+		t.Errorf("expected 3 items, got '%v'", value)
 	}
 }
 
@@ -97,6 +197,115 @@ func TestSurfaceMessagesNoData(t *testing.T) {
 	}
 }
 
+func TestSurfacePendingUpdates(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "a"))
+	s.Add(TextStatic("a", "A"))
+	s.SetData("/x", 1)
+	s.Messages()
+
+	if msgs := s.PendingUpdates(); len(msgs) != 0 {
+		t.Fatalf("expected no pending updates after Messages, got %d", len(msgs))
+	}
+
+	s.Add(TextStatic("a", "A"))     // unchanged
+	s.Add(TextStatic("b", "B"))     // added
+	s.Add(Column("root", "a", "b")) // modified
+	s.SetData("/x", 1)              // unchanged
+	s.SetData("/y", 2)              // added
+
+	msgs := s.PendingUpdates()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 pending messages, got %d", len(msgs))
+	}
+
+	comps := msgs[0].UpdateComponents.Components
+	if len(comps) != 2 {
+		t.Fatalf("expected 2 changed components, got %d", len(comps))
+	}
+	// Delta keeps surface order
+	if comps[0].(Component).ID != "root" || comps[1].(Component).ID != "b" {
+		t.Errorf("expected [root b], got [%s %s]", comps[0].(Component).ID, comps[1].(Component).ID)
+	}
+
+	contents := msgs[1].DataModelUpdate.Contents
+	if len(contents) != 1 || contents["/y"] != 2 {
+		t.Errorf("expected only /y in data delta, got %v", contents)
+	}
+
+	// PendingUpdates does not clear
+	if len(s.PendingUpdates()) != 2 {
+		t.Error("expected PendingUpdates to keep pending state")
+	}
+}
+
+func TestSurfaceFlush(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hello"))
+
+	msgs := s.Flush()
+	if len(msgs) != 1 || msgs[0].UpdateComponents == nil {
+		t.Fatalf("expected single UpdateComponents from first Flush, got %v", msgs)
+	}
+	if msgs := s.Flush(); len(msgs) != 0 {
+		t.Errorf("expected nothing after Flush, got %d messages", len(msgs))
+	}
+
+	s.SetData("/v", "x")
+	msgs = s.Flush()
+	if len(msgs) != 1 || msgs[0].DataModelUpdate == nil {
+		t.Fatalf("expected single DataModelUpdate, got %v", msgs)
+	}
+}
+
+func TestSurfacePendingAfterRemove(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hello"))
+	s.Add(TextStatic("gone", "Bye"))
+	s.Remove("gone")
+
+	msgs := s.Flush()
+	if len(msgs) != 1 || len(msgs[0].UpdateComponents.Components) != 1 {
+		t.Errorf("expected only root pending after Remove, got %v", msgs)
+	}
+}
+
+func TestSurfaceStateKeepsPending(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hello"))
+	s.SetData("/v", 1)
+
+	if got := messageKinds(s.State()); got != "test:beginRendering test:updateComponents test:dataModelUpdate" {
+		t.Errorf("unexpected state: %s", got)
+	}
+	if got := messageKinds(s.Flush()); got != "test:updateComponents test:dataModelUpdate" {
+		t.Errorf("expected State to keep pending changes, got %s", got)
+	}
+
+	s.SetData("/v", 2)
+	s.Messages()
+	if got := messageKinds(s.Flush()); got != "" {
+		t.Errorf("expected Messages to mark changes sent, got %s", got)
+	}
+}
+
+func TestSurfaceMessagesAreSnapshots(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hello"))
+	s.SetData("/v", 1)
+	msgs := s.Messages()
+
+	s.Add(TextStatic("root", "Changed"))
+	s.SetData("/v", 2)
+
+	if msgs[1].UpdateComponents.Components[0].(Component).Text != "Hello" {
+		t.Error("expected emitted components to be unaffected by later changes")
+	}
+	if msgs[2].DataModelUpdate.Contents["/v"] != 1 {
+		t.Error("expected emitted data to be unaffected by later changes")
+	}
+}
+
 func TestColumnHelper(t *testing.T) {
 	c := Column("col", "a", "b", "c")
 
@@ -974,28 +1183,16 @@ func TestValidateEmptyID(t *testing.T) {
 	}
 }
 
-func TestValidateDuplicateID(t *testing.T) {
+func TestValidateReAddedIDIsNotDuplicate(t *testing.T) {
 	s := NewSurface("test")
-	s.Add(TextStatic("root", "Hello"))
+	s.Add(Column("root", "duplicate"))
 	s.Add(TextStatic("duplicate", "First"))
 	s.Add(TextStatic("duplicate", "Second"))
 
 	errors := s.Validate()
 
-	if len(errors) == 0 {
-		t.Fatal("expected validation errors for duplicate IDs")
-	}
-
-	found := false
-	for _, err := range errors {
-		if err.ComponentID == "duplicate" && err.Field == "ID" && err.Message == "duplicate component ID" {
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		t.Error("expected error about duplicate component ID")
+	if len(errors) != 0 {
+		t.Errorf("expected no validation errors for re-added ID, got %d: %v", len(errors), errors)
 	}
 }
 
@@ -1285,20 +1482,17 @@ func TestValidationErrorFormat(t *testing.T) {
 func TestValidateMultipleErrors(t *testing.T) {
 	s := NewSurface("test")
 	s.SetRoot("missing-root")
-	s.Add(TextStatic("duplicate", "First"))
-	s.Add(TextStatic("duplicate", "Second"))
 	s.Add(Component{ID: "", Component: "Text", Text: "Empty"})
 	s.Add(Column("col", "missing-child"))
 
 	errors := s.Validate()
 
-	if len(errors) < 4 {
-		t.Errorf("expected at least 4 errors, got %d", len(errors))
+	if len(errors) < 3 {
+		t.Errorf("expected at least 3 errors, got %d", len(errors))
 	}
 
 	// Check we have various error types
 	hasRootError := false
-	hasDuplicateError := false
 	hasEmptyIDError := false
 	hasMissingChildError := false
 
@@ -1306,9 +1500,6 @@ func TestValidateMultipleErrors(t *testing.T) {
 		if err.Field == "Root" {
 			hasRootError = true
 		}
-		if err.Field == "ID" && err.Message == "duplicate component ID" {
-			hasDuplicateError = true
-		}
 		if err.Field == "ID" && err.Message == "component ID must not be empty" {
 			hasEmptyIDError = true
 		}
@@ -1320,9 +1511,6 @@ func TestValidateMultipleErrors(t *testing.T) {
 	if !hasRootError {
 		t.Error("expected root error")
 	}
-	if !hasDuplicateError {
-		t.Error("expected duplicate ID error")
-	}
 	if !hasEmptyIDError {
 		t.Error("expected empty ID error")
 	}
@@ -1512,3 +1700,52 @@ func TestCustomComponentInSurface(t *testing.T) {
 		t.Errorf("expected height field for SparklineChart, got: %s", output)
 	}
 }
+
+func TestPointerComponentEditedInPlace(t *testing.T) {
+	s := NewSurface("test")
+	root := &Component{ID: "root", Component: "Text", Text: "a"}
+	s.Add(root)
+	s.Flush()
+
+	// The surface keeps its own copy, so changing root does nothing yet
+	root.Text = "b"
+	if got := s.PendingUpdates(); len(got) != 0 {
+		t.Errorf("expected no pending updates, got %s", messageKinds(got))
+	}
+	s.Add(root)
+	if got := messageKinds(s.Flush()); got != "test:updateComponents" {
+		t.Errorf("expected re-added pointer to be pending, got %q", got)
+	}
+
+	c, _ := s.Get("root")
+	c.(*Component).Text = "c"
+	if got, _ := s.Get("root"); got.(*Component).Text != "b" {
+		t.Errorf("expected Get to return a copy, got %q", got.(*Component).Text)
+	}
+	s.Add(c)
+	if got := messageKinds(s.Flush()); got != "test:updateComponents" {
+		t.Errorf("expected edited copy to be pending, got %q", got)
+	}
+}
+
+func TestCustomComponentReplacedByID(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "gauge"))
+	s.Add(Gauge{Component: Component{ID: "gauge", Component: "Gauge"}, Color: "red"})
+	s.Add(&Gauge{Component: Component{ID: "gauge", Component: "Gauge"}, Color: "green"})
+
+	if len(s.components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(s.components))
+	}
+	c, ok := s.Get("gauge")
+	if !ok {
+		t.Fatal("expected Get to find custom component")
+	}
+	g, ok := c.(*Gauge)
+	if !ok || g.Color != "green" {
+		t.Errorf("expected replaced *Gauge with color 'green', got %#v", c)
+	}
+	if !s.Remove("gauge") {
+		t.Error("expected Remove to find custom component")
+	}
+}
@@ -0,0 +1,354 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes one event data field that could not be bound.
+// Field is the data key; nested fields and slice elements are joined
+// with dots (for example "guest.name" or "items.2").
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// BindError collects every field error found by Event.Bind.
+type BindError struct {
+	Errors []FieldError
+}
+
+func (e *BindError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Error()
+	}
+	return "a2ui: invalid event data: " + strings.Join(parts, "; ")
+}
+
+// ValidationErrors converts the field errors to validation errors
+// reported against componentID, typically the event's component.
+func (e *BindError) ValidationErrors(componentID string) []ValidationError {
+	errs := make([]ValidationError, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = ValidationError{ComponentID: componentID, Field: fe.Field, Message: fe.Message}
+	}
+	return errs
+}
+
+// ApplyTo writes each field error message into the surface's data model
+// under basePath, so "party" becomes basePath + "/party". Text components
+// bound to those paths show the errors with the next Flush.
+func (e *BindError) ApplyTo(s *Surface, basePath string) *Surface {
+	base := strings.TrimSuffix(basePath, "/")
+	for _, fe := range e.Errors {
+		s.SetData(base+"/"+strings.ReplaceAll(fe.Field, ".", "/"), fe.Message)
+	}
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts are tried in order when binding strings to time.Time.
+// They cover RFC 3339 and the values of HTML date and time inputs.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"15:04:05",
+	"15:04",
+}
+
+// Bind copies the event's Data into the struct pointed to by dst.
+//
+// Fields are matched by the name in their `a2ui` tag, falling back to the
+// `json` tag and then the field name; "-" skips a field. The tag option
+// "required" reports missing, null and empty string values:
+//
+//	type Booking struct {
+//		Name  string    `a2ui:"name,required"`
+//		Date  time.Time `a2ui:"date,required"`
+//		Party int       `a2ui:"party"`
+//	}
+//
+// Values are coerced to the field type: numbers and numeric strings bind
+// to integer and float fields, strings, numbers and booleans bind to
+// string fields, "true", "false", "on" and "off" bind to bool fields, and
+// RFC 3339, date and time strings bind to time.Time. Nested structs and
+// slices are bound recursively. Unknown keys are ignored.
+//
+// All field problems are returned together as a *BindError.
+func (e *Event) Bind(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("a2ui: Bind requires a non-nil pointer to a struct")
+	}
+
+	b := &binder{}
+	b.bindStruct(v.Elem(), e.Data, "")
+	if len(b.errs) > 0 {
+		return &BindError{Errors: b.errs}
+	}
+	return nil
+}
+
+type binder struct {
+	errs []FieldError
+}
+
+func (b *binder) fail(field, format string, args ...any) {
+	b.errs = append(b.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// bindField holds the parsed tag of a struct field.
+type bindField struct {
+	index    int
+	name     string
+	required bool
+}
+
+func bindFields(t reflect.Type) []bindField {
+	var fields []bindField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag, ok := f.Tag.Lookup("a2ui")
+		if !ok {
+			tag = f.Tag.Get("json")
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		bf := bindField{index: i, name: name}
+		for _, opt := range parts[1:] {
+			if opt == "required" {
+				bf.required = true
+			}
+		}
+		fields = append(fields, bf)
+	}
+	return fields
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (b *binder) bindStruct(v reflect.Value, data map[string]any, prefix string) {
+	for _, f := range bindFields(v.Type()) {
+		field := joinField(prefix, f.name)
+		raw, ok := data[f.name]
+		if !ok || raw == nil || raw == "" {
+			if f.required {
+				b.fail(field, "is required")
+			}
+			continue
+		}
+		b.bindValue(v.Field(f.index), raw, field)
+	}
+}
+
+func (b *binder) bindValue(v reflect.Value, raw any, field string) {
+	if v.Kind() == reflect.Ptr {
+		if raw == nil {
+			return
+		}
+		elem := reflect.New(v.Type().Elem())
+		n := len(b.errs)
+		b.bindValue(elem.Elem(), raw, field)
+		if len(b.errs) == n {
+			v.Set(elem)
+		}
+		return
+	}
+
+	if v.Type() == timeType {
+		b.bindTime(v, raw, field)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		switch r := raw.(type) {
+		case string:
+			v.SetString(r)
+		case bool:
+			v.SetString(strconv.FormatBool(r))
+		case float64:
+			v.SetString(strconv.FormatFloat(r, 'f', -1, 64))
+		case json.Number:
+			v.SetString(r.String())
+		default:
+			b.fail(field, "must be a string")
+		}
+
+	case reflect.Bool:
+		switch r := raw.(type) {
+		case bool:
+			v.SetBool(r)
+		case string:
+			switch strings.ToLower(strings.TrimSpace(r)) {
+			case "true", "on", "1":
+				v.SetBool(true)
+			case "false", "off", "0":
+				v.SetBool(false)
+			default:
+				b.fail(field, "must be a boolean")
+			}
+		default:
+			b.fail(field, "must be a boolean")
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := toFloat(raw)
+		if !ok {
+			b.fail(field, "must be a number")
+			return
+		}
+		if f != math.Trunc(f) {
+			b.fail(field, "must be a whole number")
+			return
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 || v.OverflowInt(int64(f)) {
+			b.fail(field, "is out of range")
+			return
+		}
+		v.SetInt(int64(f))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := toFloat(raw)
+		if !ok {
+			b.fail(field, "must be a number")
+			return
+		}
+		if f != math.Trunc(f) {
+			b.fail(field, "must be a whole number")
+			return
+		}
+		if f < 0 || f >= math.MaxUint64 || v.OverflowUint(uint64(f)) {
+			b.fail(field, "is out of range")
+			return
+		}
+		v.SetUint(uint64(f))
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(raw)
+		if !ok {
+			b.fail(field, "must be a number")
+			return
+		}
+		if v.OverflowFloat(f) {
+			b.fail(field, "is out of range")
+			return
+		}
+		v.SetFloat(f)
+
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			b.fail(field, "must be an object")
+			return
+		}
+		b.bindStruct(v, m, field)
+
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			b.fail(field, "must be a list")
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			b.bindValue(s.Index(i), item, joinField(field, strconv.Itoa(i)))
+		}
+		v.Set(s)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			b.fail(field, "unsupported field type %s", v.Type())
+			return
+		}
+		m, ok := raw.(map[string]any)
+		if !ok {
+			b.fail(field, "must be an object")
+			return
+		}
+		out := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			b.bindValue(elem, item, joinField(field, k))
+			out.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		v.Set(out)
+
+	case reflect.Interface:
+		if raw == nil {
+			// null leaves the zero value
+			return
+		}
+		if reflect.TypeOf(raw).AssignableTo(v.Type()) {
+			v.Set(reflect.ValueOf(raw))
+		} else {
+			b.fail(field, "unsupported field type %s", v.Type())
+		}
+
+	default:
+		b.fail(field, "unsupported field type %s", v.Type())
+	}
+}
+
+func (b *binder) bindTime(v reflect.Value, raw any, field string) {
+	s, ok := raw.(string)
+	if !ok {
+		b.fail(field, "must be a date or time")
+		return
+	}
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			v.Set(reflect.ValueOf(t))
+			return
+		}
+	}
+	b.fail(field, "must be a date or time")
+}
+
+// toFloat converts JSON numbers and numeric strings to float64.
+func toFloat(raw any) (float64, bool) {
+	switch r := raw.(type) {
+	case float64:
+		return r, true
+	case json.Number:
+		f, err := r.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(r), 64)
+		return f, err == nil
+	case int:
+		return float64(r), true
+	case int64:
+		return float64(r), true
+	}
+	return 0, false
+}
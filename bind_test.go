@@ -0,0 +1,155 @@
+package a2ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type bookingForm struct {
+	Name   string    `a2ui:"name,required"`
+	Date   time.Time `a2ui:"date,required"`
+	Party  int       `a2ui:"party"`
+	Notes  *string   `json:"notes"`
+	Agree  bool      `a2ui:"agree"`
+	Price  float64   `a2ui:"price"`
+	Tags   []string  `a2ui:"tags"`
+	Guest  guestInfo `a2ui:"guest"`
+	Secret string    `a2ui:"-"`
+}
+
+type guestInfo struct {
+	Email string `a2ui:"email,required"`
+	Age   uint8  `a2ui:"age"`
+}
+
+func TestEventBind(t *testing.T) {
+	ev := &Event{Data: map[string]any{
+		"name":    "Ada",
+		"date":    "2025-03-14",
+		"party":   float64(4),
+		"notes":   "window seat",
+		"agree":   "on",
+		"price":   "12.50",
+		"tags":    []any{"vip", float64(1)},
+		"guest":   map[string]any{"email": "ada@example.com", "age": "36"},
+		"Secret":  "ignored",
+		"unknown": true,
+	}}
+
+	var form bookingForm
+	if err := ev.Bind(&form); err != nil {
+		t.Fatal(err)
+	}
+	if form.Name != "Ada" || form.Party != 4 || !form.Agree || form.Price != 12.5 {
+		t.Errorf("unexpected scalar fields: %+v", form)
+	}
+	if !form.Date.Equal(time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date: %v", form.Date)
+	}
+	if form.Notes == nil || *form.Notes != "window seat" {
+		t.Errorf("expected notes pointer, got %v", form.Notes)
+	}
+	if len(form.Tags) != 2 || form.Tags[1] != "1" {
+		t.Errorf("unexpected tags: %v", form.Tags)
+	}
+	if form.Guest.Email != "ada@example.com" || form.Guest.Age != 36 {
+		t.Errorf("unexpected nested struct: %+v", form.Guest)
+	}
+	if form.Secret != "" {
+		t.Error("expected '-' field to be skipped")
+	}
+}
+
+func TestEventBindErrors(t *testing.T) {
+	ev := &Event{ComponentID: "submit", Data: map[string]any{
+		"name":  "",
+		"date":  "next friday",
+		"party": "2.5",
+		"agree": "maybe",
+		"guest": map[string]any{"age": float64(300)},
+	}}
+
+	var form bookingForm
+	err := ev.Bind(&form)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected *BindError, got %v", err)
+	}
+
+	want := map[string]string{
+		"name":        "is required",
+		"date":        "must be a date or time",
+		"party":       "must be a whole number",
+		"agree":       "must be a boolean",
+		"guest.email": "is required",
+		"guest.age":   "is out of range",
+	}
+	if len(bindErr.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), bindErr.Errors)
+	}
+	for _, fe := range bindErr.Errors {
+		if want[fe.Field] != fe.Message {
+			t.Errorf("%s: expected '%s', got '%s'", fe.Field, want[fe.Field], fe.Message)
+		}
+	}
+
+	verrs := bindErr.ValidationErrors(ev.ComponentID)
+	if len(verrs) != len(want) || verrs[0].ComponentID != "submit" || verrs[0].Field != "name" {
+		t.Errorf("unexpected validation errors: %v", verrs)
+	}
+}
+
+func TestEventBindNullElements(t *testing.T) {
+	ev := &Event{Data: map[string]any{
+		"tags": []any{"a", nil},
+		"m":    map[string]any{"x": nil, "y": float64(1)},
+		"any":  nil,
+	}}
+	var form struct {
+		Tags []any          `a2ui:"tags"`
+		M    map[string]any `a2ui:"m"`
+		Any  any            `a2ui:"any"`
+	}
+	if err := ev.Bind(&form); err != nil {
+		t.Fatal(err)
+	}
+	if len(form.Tags) != 2 || form.Tags[0] != "a" || form.Tags[1] != nil {
+		t.Errorf("unexpected tags: %v", form.Tags)
+	}
+	if v, ok := form.M["x"]; !ok || v != nil || form.M["y"] != float64(1) {
+		t.Errorf("unexpected map: %v", form.M)
+	}
+	if form.Any != nil {
+		t.Errorf("expected nil, got %v", form.Any)
+	}
+}
+
+func TestEventBindRequiresStructPointer(t *testing.T) {
+	ev := &Event{}
+	var form bookingForm
+	if err := ev.Bind(form); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+	if err := ev.Bind((*bookingForm)(nil)); err == nil {
+		t.Error("expected error for nil pointer")
+	}
+}
+
+func TestBindErrorApplyTo(t *testing.T) {
+	s := NewSurface("form")
+	s.Add(TextStatic("root", ""))
+	s.Flush()
+
+	err := &BindError{Errors: []FieldError{{Field: "name", Message: "is required"}, {Field: "guest.email", Message: "is required"}}}
+	err.ApplyTo(s, "/errors/")
+
+	msgs := s.Flush()
+	if len(msgs) != 1 || msgs[0].DataModelUpdate == nil {
+		t.Fatalf("expected one data update, got %+v", msgs)
+	}
+	contents := msgs[0].DataModelUpdate.Contents
+	if contents["/errors/name"] != "is required" || contents["/errors/guest/email"] != "is required" {
+		t.Errorf("unexpected error data: %v", contents)
+	}
+}
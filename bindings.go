@@ -0,0 +1,190 @@
+package a2ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bindingKinds lists the JSON types each standard component accepts at its
+// data binding. Component types not listed accept any value.
+var bindingKinds = map[string][]string{
+	"Text":           {"string", "number", "boolean"},
+	"TextField":      {"string", "number", "boolean"},
+	"Image":          {"string"},
+	"Video":          {"string"},
+	"AudioPlayer":    {"string"},
+	"DateTimeInput":  {"string"},
+	"CheckBox":       {"boolean"},
+	"Slider":         {"number"},
+	"MultipleChoice": {"array"},
+	"List":           {"array"},
+}
+
+// bindingScope is the data that binding paths are resolved against: the
+// data model's root, or the items of the enclosing List.
+type bindingScope struct {
+	list  string // ID of the enclosing List, "" at the root
+	items []any
+}
+
+// ValidateBindings checks every data binding reachable from the root
+// against the surface's data model. Paths inside a List template are
+// resolved against each item of the list's data.
+//
+// It reports paths with no data as errors (as warnings if only some list
+// items lack them) and values of the wrong JSON type, such as a List bound
+// to a scalar or a CheckBox bound to a string, as errors. Templates of
+// empty lists are not checked. Unlike Validate it depends on the data, so
+// call it once the data model is populated.
+func (s *Surface) ValidateBindings() []ValidationError {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	root, _ := s.data.Get("")
+	b := &bindingChecker{
+		s:        s,
+		onPath:   make(map[string]bool),
+		reported: make(map[string]bool),
+	}
+	b.visit(s.root, bindingScope{items: []any{root}})
+	return b.errs
+}
+
+// bindingChecker walks the render tree collecting binding errors.
+type bindingChecker struct {
+	s        *Surface
+	onPath   map[string]bool
+	reported map[string]bool
+	errs     []ValidationError
+}
+
+func (b *bindingChecker) visit(id string, scope bindingScope) {
+	if b.onPath[id] || len(scope.items) == 0 {
+		return
+	}
+	c, ok := b.s.get(id)
+	if !ok {
+		return
+	}
+	comp := asComponent(c)
+	if comp == nil {
+		return
+	}
+	b.onPath[id] = true
+	defer delete(b.onPath, id)
+
+	var values []any
+	if comp.DataBinding != nil && comp.DataBinding.Path != "" {
+		values = b.check(comp, scope)
+	}
+	for _, ref := range childRefs(comp) {
+		if ref.Label != "template" {
+			b.visit(ref.ID, scope)
+			continue
+		}
+		// Templates are rendered once per item of the bound array
+		items := bindingScope{list: comp.ID}
+		for _, v := range values {
+			if container, ok := asContainer(v); ok {
+				if arr, ok := container.([]any); ok {
+					items.items = append(items.items, arr...)
+				}
+			}
+		}
+		b.visit(ref.ID, items)
+	}
+}
+
+// check resolves comp's binding in scope and returns the values found.
+func (b *bindingChecker) check(comp *Component, scope bindingScope) []any {
+	path := comp.DataBinding.Path
+	tokens := splitPointer(path)
+	field := comp.Component + ".DataBinding"
+	kinds, typed := bindingKinds[comp.Component]
+
+	var values []any
+	var mismatch string
+	missing := 0
+	for i, item := range scope.items {
+		v, ok := lookupPointer(item, tokens)
+		if !ok {
+			missing++
+			continue
+		}
+		values = append(values, v)
+		if kind := jsonKind(v); typed && mismatch == "" && kind != "null" && !containsString(kinds, kind) {
+			mismatch = fmt.Sprintf("data at '%s' is %s, expected %s", path, kind, joinOr(kinds))
+			if scope.list != "" {
+				mismatch += fmt.Sprintf(" (item %d of list '%s')", i, scope.list)
+			}
+		}
+	}
+
+	switch {
+	case missing == 0:
+	case scope.list == "":
+		b.add(comp.ID, field, SeverityError, "no data at '%s'", path)
+	case missing == len(scope.items):
+		b.add(comp.ID, field, SeverityError, "no data at '%s' in any item of list '%s'", path, scope.list)
+	default:
+		b.add(comp.ID, field, SeverityWarning, "no data at '%s' in %d of %d items of list '%s'",
+			path, missing, len(scope.items), scope.list)
+	}
+	if mismatch != "" {
+		b.add(comp.ID, field, SeverityError, "%s", mismatch)
+	}
+	return values
+}
+
+// add records an error once, even if its component is rendered in several places.
+func (b *bindingChecker) add(id, field string, severity Severity, format string, args ...any) {
+	e := ValidationError{
+		ComponentID: id,
+		Field:       field,
+		Message:     fmt.Sprintf(format, args...),
+		Severity:    severity,
+	}
+	if key := e.Error(); !b.reported[key] {
+		b.reported[key] = true
+		b.errs = append(b.errs, e)
+	}
+}
+
+// jsonKind returns the JSON type name of v.
+func jsonKind(v any) string {
+	g, err := toGeneric(v)
+	if err != nil {
+		return fmt.Sprintf("%T", v)
+	}
+	switch g.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// joinOr joins words as "a", "a or b" or "a, b or c".
+func joinOr(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " or " + words[len(words)-1]
+}
@@ -0,0 +1,80 @@
+package a2ui
+
+import (
+	"testing"
+)
+
+type bindingProduct struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price,omitempty"`
+}
+
+func TestValidateBindings(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "title", "agree", "list", "missing"))
+	s.Add(TextBound("title", "/user"))
+	s.Add(CheckBoxBound("agree", "Agree", "/agree"))
+	s.Add(ListTemplate("list", "card", "/products"))
+	s.Add(Card("card", "row"))
+	s.Add(Row("row", "name", "price"))
+	s.Add(TextBound("name", "/name"))
+	s.Add(TextBound("price", "/price"))
+	s.Add(TextBound("missing", "/nope"))
+
+	s.SetData("/user", map[string]any{"name": "Ada"})
+	s.SetData("/agree", "yes")
+	s.SetData("/products", []bindingProduct{{Name: "Tea", Price: 3}, {Name: "Cup"}})
+
+	errs := s.ValidateBindings()
+	tests := []struct {
+		id, message string
+		severity    Severity
+	}{
+		{"title", "data at '/user' is object, expected string, number or boolean", SeverityError},
+		{"agree", "data at '/agree' is string, expected boolean", SeverityError},
+		{"price", "no data at '/price' in 1 of 2 items of list 'list'", SeverityWarning},
+		{"missing", "no data at '/nope'", SeverityError},
+	}
+	for _, tt := range tests {
+		e := findValidationError(errs, tt.id, tt.message)
+		if e == nil || e.Severity != tt.severity || e.Field != "Text.DataBinding" && e.Field != "CheckBox.DataBinding" {
+			t.Errorf("expected %s %q on %s, got %v", tt.severity, tt.message, tt.id, errs)
+		}
+	}
+	if len(errs) != len(tests) {
+		t.Errorf("expected %d binding errors, got %v", len(tests), errs)
+	}
+}
+
+func TestValidateBindingsListScope(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(ListTemplate("root", "order", "/orders"))
+	s.Add(ListTemplate("order", "item", "/items"))
+	s.Add(TextBound("item", "/sku"))
+
+	s.SetData("/orders", []any{
+		map[string]any{"items": []any{map[string]any{"sku": "a"}}},
+		map[string]any{"items": []any{map[string]any{"sku": 7}}},
+	})
+	if errs := s.ValidateBindings(); len(errs) != 0 {
+		t.Errorf("expected nested list bindings to resolve per item, got %v", errs)
+	}
+
+	s.SetData("/orders/1/items", "none")
+	errs := s.ValidateBindings()
+	e := findValidationError(errs, "order", "data at '/items' is string, expected array (item 1 of list 'root')")
+	if e == nil || e.Field != "List.DataBinding" {
+		t.Errorf("expected list bound to a scalar, got %v", errs)
+	}
+
+	s.SetData("/orders", "none")
+	errs = s.ValidateBindings()
+	if len(errs) != 1 || errs[0].Message != "data at '/orders' is string, expected array" {
+		t.Errorf("expected only the root list mismatch, got %v", errs)
+	}
+
+	s.SetData("/orders", []any{})
+	if errs := s.ValidateBindings(); len(errs) != 0 {
+		t.Errorf("expected the template of an empty list to be skipped, got %v", errs)
+	}
+}
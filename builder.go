@@ -1,245 +1,526 @@
 package a2ui
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
 
 // Surface builds A2UI messages for a UI surface.
+// Components are keyed by ID and kept in first-insertion order.
+// The surface tracks which components and data paths changed since the
+// last emitted message so that updates can be sent incrementally.
+//
+// A Surface is safe for concurrent use. Each method is atomic, and Batch
+// groups several changes so that Messages, PendingUpdates and Flush
+// return either none or all of them. Get and Data return copies, so
+// state can only be changed through the surface.
 type Surface struct {
+	mu         sync.RWMutex
 	id         string
 	root       string
 	components []any
-	data       map[string]any
+	index      map[string]int
+	data       *DataModel
+	registry   *Registry
+	protocol   ProtocolVersion
+	patches    bool
+
+	pendingComponents map[string]bool
 }
 
 // NewSurface creates a new surface with the given ID.
 // The root component ID defaults to "root".
 func NewSurface(id string) *Surface {
 	return &Surface{
-		id:   id,
-		root: "root",
-		data: make(map[string]any),
+		id:    id,
+		root:  "root",
+		index: make(map[string]int),
+		data:  NewDataModel(),
+
+		pendingComponents: make(map[string]bool),
 	}
 }
 
+// ID returns the surface ID.
+func (s *Surface) ID() string {
+	return s.id
+}
+
+// Root returns the root component ID.
+func (s *Surface) Root() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.root
+}
+
 // SetRoot sets the root component ID.
 func (s *Surface) SetRoot(id string) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.root = id
 	return s
 }
 
-// Add appends a component to the surface.
+// SetProtocol sets the protocol version the surface's messages are intended
+// for. It controls message ordering in Messages; use an Encoder with the same
+// version to write them.
+func (s *Surface) SetProtocol(v ProtocolVersion) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocol = v
+	return s
+}
+
+// Protocol returns the protocol version set with SetProtocol.
+func (s *Surface) Protocol() ProtocolVersion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protocol
+}
+
+// SetRegistry sets the component registry used by Validate and Clone.
+// When set, Validate reports components whose type is not registered.
+func (s *Surface) SetRegistry(r *Registry) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registry = r
+	return s
+}
+
+// Add adds a component to the surface. If a component with the same ID
+// already exists it is replaced in place, keeping its original position,
+// and marked pending if it differs.
 // The component can be of type Component or any custom struct with embedded Component.
+// A pointer component is copied, so changing it later has no effect until
+// it is added again.
 func (s *Surface) Add(c any) *Surface {
-	s.components = append(s.components, c)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(c)
 	return s
 }
 
-// AddAll appends multiple standard components to the surface.
-// For adding custom components, use Add() individually.
+// AddAll adds multiple standard components to the surface with the same
+// replace semantics as Add. For adding custom components, use Add() individually.
 func (s *Surface) AddAll(components ...Component) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, c := range components {
-		s.components = append(s.components, c)
+		s.put(c)
 	}
 	return s
 }
 
-// SetData sets a value at the given JSON Pointer path.
-func (s *Surface) SetData(path string, value any) *Surface {
-	s.data[path] = value
-	return s
+// Replace replaces the component with the same ID as c.
+// It reports whether such a component existed; if not, c is not added.
+func (s *Surface) Replace(c any) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replace(c)
 }
 
-// Messages returns the complete message sequence for this surface.
-func (s *Surface) Messages() []Message {
-	messages := []Message{
-		{BeginRendering: &BeginRendering{SurfaceID: s.id, Root: s.root}},
-		{UpdateComponents: &UpdateComponents{SurfaceID: s.id, Components: s.components}},
+// Remove removes the component with the given ID.
+// It reports whether the component existed.
+//
+// A2UI has no message that deletes a component, so Remove produces no
+// pending update: a client that already received the component keeps it
+// until the parents referring to it are resent without it. Update those
+// parents in the same Batch.
+func (s *Surface) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remove(id)
+}
+
+// Get returns the component with the given ID. A pointer component is
+// returned as a copy; change it and add it again to update the surface.
+func (s *Surface) Get(id string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.get(id)
+}
+
+func (s *Surface) get(id string) (any, bool) {
+	i, ok := s.index[id]
+	if !ok {
+		return nil, false
 	}
+	return copyComponent(s.components[i]), true
+}
 
-	if len(s.data) > 0 {
-		messages = append(messages, Message{
-			DataModelUpdate: &DataModelUpdate{SurfaceID: s.id, Contents: s.data},
-		})
+func (s *Surface) replace(c any) bool {
+	i, ok := s.index[componentID(c)]
+	if !ok {
+		return false
 	}
+	s.set(i, copyComponent(c))
+	return true
+}
 
-	return messages
+func (s *Surface) remove(id string) bool {
+	i, ok := s.index[id]
+	if !ok {
+		return false
+	}
+	s.components = append(s.components[:i], s.components[i+1:]...)
+	delete(s.index, id)
+	delete(s.pendingComponents, id)
+	for j := i; j < len(s.components); j++ {
+		if cid := componentID(s.components[j]); cid != "" {
+			s.index[cid] = j
+		}
+	}
+	return true
 }
 
-// UpdateComponentsMessage returns an UpdateComponents message with current components.
-func (s *Surface) UpdateComponentsMessage() Message {
-	return Message{
-		UpdateComponents: &UpdateComponents{SurfaceID: s.id, Components: s.components},
+// put inserts or replaces c. Components without an ID are appended
+// unindexed so that Validate can still report them.
+func (s *Surface) put(c any) {
+	c = copyComponent(c)
+	id := componentID(c)
+	if id == "" {
+		s.components = append(s.components, c)
+		return
+	}
+	if i, ok := s.index[id]; ok {
+		s.set(i, c)
+		return
 	}
+	s.index[id] = len(s.components)
+	s.components = append(s.components, c)
+	s.pendingComponents[id] = true
 }
 
-// DataModelUpdateMessage returns a DataModelUpdate message with current data.
-func (s *Surface) DataModelUpdateMessage() Message {
-	return Message{
-		DataModelUpdate: &DataModelUpdate{SurfaceID: s.id, Contents: s.data},
+// set replaces the component at index i, marking it pending only if it
+// changed. c must not be shared with the caller (see copyComponent).
+func (s *Surface) set(i int, c any) {
+	if reflect.DeepEqual(s.components[i], c) {
+		return
 	}
+	s.components[i] = c
+	s.pendingComponents[componentID(c)] = true
 }
 
-// Components returns the current component list.
-func (s *Surface) Components() []any {
-	return s.components
+// copyComponent returns a deep copy of c if it is a pointer, made through
+// JSON like Registry.Clone, so that the surface never shares a component
+// the caller can change in place. Other values are returned as is.
+func copyComponent(c any) any {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return c
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return c
+	}
+	cp, err := decodeAs(data, v.Type())
+	if err != nil {
+		return c
+	}
+	return cp
+}
+
+// SetData sets a value at the given JSON Pointer path, creating
+// intermediate objects as needed. Setting "/user" and then "/user/name"
+// updates the same tree. Values that cannot be stored at path (for example
+// below a string) are ignored; use Batch.Data().Set to get the error.
+func (s *Surface) SetData(path string, value any) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Set(path, value)
+	return s
+}
+
+// SetDataPatches makes PendingUpdates and Flush send data changes as a
+// DataModelPatch with the recorded operations instead of a DataModelUpdate.
+// Enable it before changing data. Messages still sends a full
+// DataModelUpdate, and surfaces targeting ProtocolV08 or ProtocolV09
+// always use DataModelUpdate since those versions have no patch message.
+func (s *Surface) SetDataPatches(on bool) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patches = on
+	s.data.SetRecording(on)
+	return s
+}
+
+// Data returns a copy of the surface's data model for reading. Changes
+// to the copy do not affect the surface; use SetData or Batch.Data.
+func (s *Surface) Data() *DataModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Clone()
+}
+
+// Batch calls fn with the surface locked and returns the surface. Other
+// goroutines observe either none or all of fn's changes; in particular
+// Messages, PendingUpdates and Flush never return part of them. fn must
+// use the surface only through b; calling methods of the surface itself
+// deadlocks.
+func (s *Surface) Batch(fn func(b *Batch)) *Surface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&Batch{s: s})
+	return s
+}
+
+// Batch changes a locked surface; see Surface.Batch. It must not be used
+// after fn returns.
+type Batch struct {
+	s *Surface
+}
+
+// Add adds or replaces a component like Surface.Add.
+func (b *Batch) Add(c any) *Batch {
+	b.s.put(c)
+	return b
+}
+
+// Replace replaces a component like Surface.Replace.
+func (b *Batch) Replace(c any) bool {
+	return b.s.replace(c)
+}
+
+// Remove removes a component like Surface.Remove, without a pending update.
+func (b *Batch) Remove(id string) bool {
+	return b.s.remove(id)
+}
+
+// Get returns a component like Surface.Get, copying pointer components.
+func (b *Batch) Get(id string) (any, bool) {
+	return b.s.get(id)
+}
+
+// SetData sets a value like Surface.SetData.
+func (b *Batch) SetData(path string, value any) *Batch {
+	b.s.data.Set(path, value)
+	return b
 }
 
-// ValidationError represents a validation error for a component.
-type ValidationError struct {
-	ComponentID string
-	Field       string
-	Message     string
+// Data returns the surface's live data model, for changes beyond SetData
+// such as Delete, Append or Set with its error.
+func (b *Batch) Data() *DataModel {
+	return b.s.data
 }
 
-func (e ValidationError) Error() string {
-	if e.ComponentID != "" {
-		return fmt.Sprintf("%s: %s - %s", e.ComponentID, e.Field, e.Message)
+// Messages returns the complete message sequence for this surface and
+// marks all pending changes as sent, so that the next Flush only returns
+// later changes. Use State to get the sequence without marking anything.
+// For ProtocolV08 BeginRendering is sent last, after components and data;
+// otherwise it is sent first.
+func (s *Surface) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.state()
+	s.pendingComponents = make(map[string]bool)
+	s.data.ResetChanges()
+	return messages
+}
+
+// State returns the same message sequence as Messages but leaves pending
+// changes untouched, for logging, caching or previews.
+func (s *Surface) State() []Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state()
+}
+
+func (s *Surface) state() []Message {
+	begin := Message{BeginRendering: &BeginRendering{SurfaceID: s.id, Root: s.root}}
+
+	var messages []Message
+	if s.protocol != ProtocolV08 {
+		messages = append(messages, begin)
+	}
+	messages = append(messages, s.updateComponentsMessage())
+	if !s.data.Empty() {
+		messages = append(messages, s.dataModelUpdateMessage())
+	}
+	if s.protocol == ProtocolV08 {
+		messages = append(messages, begin)
 	}
-	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	return messages
 }
 
-// Validate checks the surface for structural errors.
-// It returns a slice of validation errors (empty slice means valid).
-// Note: Validation only works for standard Component types. Custom components
-// with embedded Component are validated for their embedded fields only.
-func (s *Surface) Validate() []ValidationError {
-	var errors []ValidationError
+// UpdateComponentsMessage returns an UpdateComponents message with all
+// current components and marks pending component changes as sent.
+func (s *Surface) UpdateComponentsMessage() Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingComponents = make(map[string]bool)
+	return s.updateComponentsMessage()
+}
 
-	// Build a map of all component IDs
-	componentIDs := make(map[string]bool)
-	duplicates := make(map[string]bool)
+func (s *Surface) updateComponentsMessage() Message {
+	components := make([]any, len(s.components))
+	copy(components, s.components)
+	return Message{
+		UpdateComponents: &UpdateComponents{SurfaceID: s.id, Components: components},
+	}
+}
 
-	// Helper to extract Component from any type
-	getComponent := func(c any) *Component {
-		switch v := c.(type) {
-		case Component:
-			return &v
-		case *Component:
-			return v
-		default:
-			return nil
-		}
+// DataModelUpdateMessage returns a DataModelUpdate message with all current
+// data, flattened to leaf paths, and marks pending data changes as sent.
+func (s *Surface) DataModelUpdateMessage() Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.ResetChanges()
+	return s.dataModelUpdateMessage()
+}
+
+func (s *Surface) dataModelUpdateMessage() Message {
+	return Message{
+		DataModelUpdate: &DataModelUpdate{SurfaceID: s.id, Contents: s.data.Contents()},
 	}
+}
 
-	// First pass: collect IDs and detect duplicates/empty IDs
-	for _, c := range s.components {
-		comp := getComponent(c)
-		if comp == nil {
-			// Custom component - skip validation but try to get ID via reflection would be complex
-			// For now, custom components are not validated
-			continue
-		}
+// PendingUpdates returns the messages needed to bring a client up to date
+// with changes made since the last emitted message: an UpdateComponents with
+// only the added or modified components and a DataModelUpdate with only the
+// changed paths (or a DataModelPatch, see SetDataPatches). Messages without
+// changes are omitted. Pending state is kept;
+// use Flush to also mark the changes as sent.
+func (s *Surface) PendingUpdates() []Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pendingUpdates()
+}
 
-		if comp.ID == "" {
-			errors = append(errors, ValidationError{
-				ComponentID: "",
-				Field:       "ID",
-				Message:     "component ID must not be empty",
-			})
-			continue
-		}
+func (s *Surface) pendingUpdates() []Message {
+	var messages []Message
 
-		if componentIDs[comp.ID] {
-			if !duplicates[comp.ID] {
-				errors = append(errors, ValidationError{
-					ComponentID: comp.ID,
-					Field:       "ID",
-					Message:     "duplicate component ID",
-				})
-				duplicates[comp.ID] = true
+	if len(s.pendingComponents) > 0 {
+		var components []any
+		for _, c := range s.components {
+			if s.pendingComponents[componentID(c)] {
+				components = append(components, c)
 			}
-		} else {
-			componentIDs[comp.ID] = true
 		}
-	}
-
-	// Check if root exists
-	if !componentIDs[s.root] {
-		errors = append(errors, ValidationError{
-			ComponentID: s.root,
-			Field:       "Root",
-			Message:     "root component not found",
+		messages = append(messages, Message{
+			UpdateComponents: &UpdateComponents{SurfaceID: s.id, Components: components},
 		})
 	}
 
-	// Second pass: check children references based on component type
-	for _, c := range s.components {
-		comp := getComponent(c)
-		if comp == nil {
-			continue
+	if s.data.HasChanges() {
+		if patch := s.data.Patch(); s.patches && s.protocol == ProtocolDefault && len(patch) > 0 {
+			messages = append(messages, Message{
+				DataModelPatch: &DataModelPatch{SurfaceID: s.id, Patch: patch},
+			})
+		} else {
+			messages = append(messages, Message{
+				DataModelUpdate: &DataModelUpdate{SurfaceID: s.id, Contents: s.data.Changes()},
+			})
 		}
+	}
 
-		// Skip components with empty IDs (already reported)
-		if comp.ID == "" {
-			continue
-		}
+	return messages
+}
 
-		switch comp.Component {
-		case "Column", "Row":
-			for _, child := range comp.Children {
-				if !componentIDs[child] {
-					errors = append(errors, ValidationError{
-						ComponentID: comp.ID,
-						Field:       comp.Component + ".Children",
-						Message:     fmt.Sprintf("child '%s' not found", child),
-					})
-				}
-			}
+// Flush returns PendingUpdates and marks all pending changes as sent.
+func (s *Surface) Flush() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.pendingUpdates()
+	s.pendingComponents = make(map[string]bool)
+	s.data.ResetChanges()
+	return messages
+}
 
-		case "Card":
-			if comp.Child != "" && !componentIDs[comp.Child] {
-				errors = append(errors, ValidationError{
-					ComponentID: comp.ID,
-					Field:       "Card.Child",
-					Message:     fmt.Sprintf("child '%s' not found", comp.Child),
-				})
-			}
+// Components returns a copy of the current component list. Pointer
+// components are copied as by Get.
+func (s *Surface) Components() []any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	components := make([]any, len(s.components))
+	for i, c := range s.components {
+		components[i] = copyComponent(c)
+	}
+	return components
+}
 
-		case "Button":
-			if comp.Child != "" && !componentIDs[comp.Child] {
-				errors = append(errors, ValidationError{
-					ComponentID: comp.ID,
-					Field:       "Button.Child",
-					Message:     fmt.Sprintf("child '%s' not found", comp.Child),
-				})
-			}
+// Clone returns a deep copy of the surface. Components are copied through
+// the surface registry (DefaultRegistry if none is set) so custom components
+// keep their types. The data model is copied deeply; leaf values are shared.
+func (s *Surface) Clone() (*Surface, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reg := s.registry
+	if reg == nil {
+		reg = DefaultRegistry
+	}
 
-		case "List":
-			if comp.Template != "" && !componentIDs[comp.Template] {
-				errors = append(errors, ValidationError{
-					ComponentID: comp.ID,
-					Field:       "List.Template",
-					Message:     fmt.Sprintf("template '%s' not found", comp.Template),
-				})
-			}
+	c := NewSurface(s.id)
+	c.root = s.root
+	c.registry = s.registry
+	c.protocol = s.protocol
+	c.patches = s.patches
+	for _, comp := range s.components {
+		cloned, err := reg.Clone(comp)
+		if err != nil {
+			return nil, fmt.Errorf("a2ui: clone %s: %w", componentID(comp), err)
+		}
+		c.components = append(c.components, cloned)
+	}
+	for id, i := range s.index {
+		c.index[id] = i
+	}
+	c.data = s.data.Clone()
+	for id := range s.pendingComponents {
+		c.pendingComponents[id] = true
+	}
+	return c, nil
+}
 
-		case "Tabs":
-			for i, tab := range comp.Tabs {
-				if !componentIDs[tab.Child] {
-					errors = append(errors, ValidationError{
-						ComponentID: comp.ID,
-						Field:       fmt.Sprintf("Tabs.Tabs[%d].Child", i),
-						Message:     fmt.Sprintf("child '%s' not found", tab.Child),
-					})
-				}
-			}
+// ComponentGetter is implemented by custom component types that hold their
+// Component in a way reflection cannot find, for example in a named field.
+// Types that embed Component do not need it.
+type ComponentGetter interface {
+	GetComponent() *Component
+}
 
-		case "Modal":
-			if comp.EntryPointChild != "" && !componentIDs[comp.EntryPointChild] {
-				errors = append(errors, ValidationError{
-					ComponentID: comp.ID,
-					Field:       "Modal.EntryPointChild",
-					Message:     fmt.Sprintf("entryPointChild '%s' not found", comp.EntryPointChild),
-				})
-			}
-			if comp.ContentChild != "" && !componentIDs[comp.ContentChild] {
-				errors = append(errors, ValidationError{
-					ComponentID: comp.ID,
-					Field:       "Modal.ContentChild",
-					Message:     fmt.Sprintf("contentChild '%s' not found", comp.ContentChild),
-				})
-			}
+var componentType = reflect.TypeOf(Component{})
+
+// asComponent returns the Component of c. It accepts Component, *Component,
+// ComponentGetter implementations and structs (or pointers to structs) that
+// embed Component.
+func asComponent(c any) *Component {
+	switch v := c.(type) {
+	case Component:
+		return &v
+	case *Component:
+		return v
+	case ComponentGetter:
+		return v.GetComponent()
+	}
+
+	v := reflect.ValueOf(c)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
 		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
 	}
+	f, ok := v.Type().FieldByName("Component")
+	if !ok || !f.Anonymous || f.Type != componentType {
+		return nil
+	}
+	fv, err := v.FieldByIndexErr(f.Index)
+	if err != nil {
+		return nil
+	}
+	comp := fv.Interface().(Component)
+	return &comp
+}
 
-	return errors
+// componentID returns the ID of c, or "" if c has no Component.
+func componentID(c any) string {
+	if comp := asComponent(c); comp != nil {
+		return comp.ID
+	}
+	return ""
 }
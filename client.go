@@ -0,0 +1,318 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ClientState reconstructs what a client knows from the messages it
+// receives: the surfaces, their roots, components and data models. Use it
+// to test agents without a renderer or to build server-side previews.
+//
+// Messages are applied as a renderer applies them. Components and data
+// may arrive before BeginRendering (as in ProtocolV08), later components
+// replace earlier ones with the same ID and DeleteSurface forgets the
+// surface. A ClientState is safe for concurrent use.
+type ClientState struct {
+	mu       sync.RWMutex
+	surfaces map[string]*clientSurface
+	order    []string
+}
+
+// clientSurface is the state of one surface.
+type clientSurface struct {
+	root       string
+	begun      bool
+	components []any
+	index      map[string]int
+	data       *DataModel
+}
+
+// NewClientState creates a client state without surfaces.
+func NewClientState() *ClientState {
+	return &ClientState{surfaces: make(map[string]*clientSurface)}
+}
+
+// Apply applies one message. It fails if the message does not have
+// exactly one payload or its data cannot be applied; the state is then
+// left as far as it was applied.
+func (c *ClientState) Apply(msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := msg.SurfaceID()
+	if msg.Kind() == MessageKindDeleteSurface {
+		c.delete(id)
+		return nil
+	}
+	s, ok := c.surfaces[id]
+	if !ok {
+		s = &clientSurface{root: "root", index: make(map[string]int), data: NewDataModel()}
+		c.surfaces[id] = s
+		c.order = append(c.order, id)
+	}
+
+	switch msg.Kind() {
+	case MessageKindBeginRendering:
+		s.begun = true
+		if msg.BeginRendering.Root != "" {
+			s.root = msg.BeginRendering.Root
+		}
+	case MessageKindUpdateComponents:
+		for _, comp := range msg.UpdateComponents.Components {
+			cid := componentID(comp)
+			if i, ok := s.index[cid]; ok {
+				s.components[i] = comp
+				continue
+			}
+			s.index[cid] = len(s.components)
+			s.components = append(s.components, comp)
+		}
+	case MessageKindDataModelUpdate:
+		if err := s.data.Apply(msg.DataModelUpdate); err != nil {
+			return fmt.Errorf("a2ui: client: surface '%s': %w", id, err)
+		}
+	case MessageKindDataModelPatch:
+		if err := s.data.ApplyPatch(msg.DataModelPatch.Patch); err != nil {
+			return fmt.Errorf("a2ui: client: surface '%s': %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ApplyAll applies messages in order and stops at the first error.
+func (c *ClientState) ApplyAll(messages []Message) error {
+	for i, msg := range messages {
+		if err := c.Apply(msg); err != nil {
+			return fmt.Errorf("a2ui: client: message %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *ClientState) delete(id string) {
+	delete(c.surfaces, id)
+	for i, v := range c.order {
+		if v == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// SurfaceIDs returns the IDs of the known surfaces in order of their first
+// message, including surfaces that have not begun rendering.
+func (c *ClientState) SurfaceIDs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ids := make([]string, len(c.order))
+	copy(ids, c.order)
+	return ids
+}
+
+// Begun reports whether the surface has received BeginRendering.
+func (c *ClientState) Begun(surfaceID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.surfaces[surfaceID]
+	return ok && s.begun
+}
+
+// Root returns the root component ID of the surface.
+func (c *ClientState) Root(surfaceID string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.surfaces[surfaceID]
+	if !ok {
+		return "", false
+	}
+	return s.root, true
+}
+
+// Component returns the current definition of a component.
+func (c *ClientState) Component(surfaceID, id string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.surfaces[surfaceID]
+	if !ok {
+		return nil, false
+	}
+	i, ok := s.index[id]
+	if !ok {
+		return nil, false
+	}
+	return s.components[i], true
+}
+
+// Components returns the surface's components in order of first arrival.
+func (c *ClientState) Components(surfaceID string) []any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.surfaces[surfaceID]
+	if !ok {
+		return nil
+	}
+	components := make([]any, len(s.components))
+	copy(components, s.components)
+	return components
+}
+
+// Data returns the value at a JSON Pointer path in the surface's data
+// model. The value must not be modified.
+func (c *ClientState) Data(surfaceID, path string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.surfaces[surfaceID]
+	if !ok {
+		return nil, false
+	}
+	return s.data.Get(path)
+}
+
+// RenderNode is a component as rendered: its definition, the data its
+// binding resolves to and its rendered children. A List has one child per
+// item of its bound array, each a rendering of the template for that item.
+type RenderNode struct {
+	ID   string
+	Type string
+
+	// Component is the definition as received.
+	Component any
+
+	// Value is the data at the component's binding and Bound reports
+	// whether it was found. Inside a List template, paths are resolved
+	// against the list item.
+	Value any
+	Bound bool
+
+	// Item is the index of the List item the node is rendered for, or -1
+	// outside List templates.
+	Item int
+
+	Children []*RenderNode
+}
+
+// Find returns the first node with the given component ID in preorder, or
+// nil. Components inside List templates are found for the first item.
+func (n *RenderNode) Find(id string) *RenderNode {
+	if n.ID == id {
+		return n
+	}
+	for _, child := range n.Children {
+		if found := child.Find(id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// String returns an indented outline of the tree, one node per line: the
+// type and ID, the List item index, and the bound value as JSON or the
+// component's literal text.
+func (n *RenderNode) String() string {
+	var sb strings.Builder
+	n.write(&sb, 0)
+	return sb.String()
+}
+
+func (n *RenderNode) write(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	if n.Item >= 0 {
+		fmt.Fprintf(sb, "[%d] ", n.Item)
+	}
+	sb.WriteString(n.Type)
+	sb.WriteByte(' ')
+	sb.WriteString(n.ID)
+	if n.Bound {
+		if data, err := json.Marshal(n.Value); err == nil {
+			sb.WriteString(" = ")
+			sb.Write(data)
+		}
+	} else if comp := asComponent(n.Component); comp != nil && comp.Text != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.Quote(comp.Text))
+	}
+	sb.WriteByte('\n')
+	for _, child := range n.Children {
+		child.write(sb, depth+1)
+	}
+}
+
+// Render returns the surface's render tree from its root. Like a
+// renderer, it skips references to components that have not arrived yet.
+// It fails if the surface is unknown, has not begun rendering, lacks its
+// root component or refers to components in a cycle.
+func (c *ClientState) Render(surfaceID string) (*RenderNode, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.surfaces[surfaceID]
+	if !ok {
+		return nil, fmt.Errorf("a2ui: client: unknown surface '%s'", surfaceID)
+	}
+	if !s.begun {
+		return nil, fmt.Errorf("a2ui: client: surface '%s' has not begun rendering", surfaceID)
+	}
+	if _, ok := s.index[s.root]; !ok {
+		return nil, fmt.Errorf("a2ui: client: surface '%s' has no root component '%s'", surfaceID, s.root)
+	}
+	root, _ := s.data.Get("")
+	r := &clientRenderer{s: s, onPath: make(map[string]bool)}
+	return r.render(s.root, root, -1)
+}
+
+// clientRenderer builds render trees for one surface.
+type clientRenderer struct {
+	s      *clientSurface
+	onPath map[string]bool
+}
+
+// render renders component id with bindings resolved against scope.
+func (r *clientRenderer) render(id string, scope any, item int) (*RenderNode, error) {
+	if r.onPath[id] {
+		return nil, fmt.Errorf("a2ui: client: cycle at component '%s'", id)
+	}
+	def := r.s.components[r.s.index[id]]
+	node := &RenderNode{ID: id, Component: def, Item: item}
+	comp := asComponent(def)
+	if comp == nil {
+		return node, nil
+	}
+	node.Type = comp.Component
+	if comp.DataBinding != nil && comp.DataBinding.Path != "" {
+		node.Value, node.Bound = lookupPointer(scope, splitPointer(comp.DataBinding.Path))
+	}
+
+	r.onPath[id] = true
+	defer delete(r.onPath, id)
+	for _, ref := range childRefs(comp) {
+		if _, ok := r.s.index[ref.ID]; !ok {
+			continue
+		}
+		if ref.Label != "template" {
+			child, err := r.render(ref.ID, scope, item)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+			continue
+		}
+		var items []any
+		if container, ok := asContainer(node.Value); ok {
+			items, _ = container.([]any)
+		}
+		for i, v := range items {
+			child, err := r.render(ref.ID, v, i)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+	}
+	return node, nil
+}
@@ -0,0 +1,113 @@
+package a2ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClientStateRender(t *testing.T) {
+	s := NewSurface("main")
+	s.Add(Column("root", "title", "people", "missing"))
+	s.Add(TextBound("title", "/title"))
+	s.Add(ListTemplate("people", "person", "/people"))
+	s.Add(Card("person", "name"))
+	s.Add(TextBound("name", "/name"))
+	s.SetData("/title", "Team")
+	s.SetData("/people", []map[string]any{{"name": "Ada"}, {"name": "Grace"}})
+
+	s.SetDataPatches(true)
+
+	c := NewClientState()
+	if err := c.ApplyAll(s.Messages()); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := c.Render("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Column root
+  Text title = "Team"
+  List people = [{"name":"Ada"},{"name":"Grace"}]
+    [0] Card person
+      [0] Text name = "Ada"
+    [1] Card person
+      [1] Text name = "Grace"
+`
+	if got := tree.String(); got != want {
+		t.Errorf("unexpected tree:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	// Updates replace components in place and change data
+	s.Add(TextStatic("title", "Members"))
+	s.Batch(func(b *Batch) { b.Data().Delete("/people/1") })
+	s.SetData("/people/0/name", "Ada L.")
+	if err := c.ApplyAll(s.Flush()); err != nil {
+		t.Fatal(err)
+	}
+	tree, _ = c.Render("main")
+	if title := tree.Find("title"); title.Bound || title.Component.(Component).Text != "Members" {
+		t.Errorf("expected replaced title, got %+v", title)
+	}
+	if name := tree.Find("name"); name.Value != "Ada L." || name.Item != 0 {
+		t.Errorf("expected updated item, got %+v", name)
+	}
+	if n := len(tree.Find("people").Children); n != 1 {
+		t.Errorf("expected one item, got %d", n)
+	}
+	if v, ok := c.Data("main", "/title"); !ok || v != "Team" {
+		t.Errorf("unexpected data: %v", v)
+	}
+}
+
+func TestClientStateLifecycle(t *testing.T) {
+	c := NewClientState()
+	v08 := NewSurface("old").SetProtocol(ProtocolV08).SetRoot("page").Add(TextStatic("page", "Hi"))
+	msgs := v08.Messages()
+
+	// Components before BeginRendering are kept but not rendered yet
+	if err := c.Apply(msgs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Render("old"); err == nil || err.Error() != "a2ui: client: surface 'old' has not begun rendering" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	c.Apply(msgs[1])
+	if root, _ := c.Root("old"); root != "page" || !c.Begun("old") {
+		t.Errorf("expected begun surface with root page, got %s", root)
+	}
+	if tree, err := c.Render("old"); err != nil || tree.String() != "Text page \"Hi\"\n" {
+		t.Errorf("unexpected render: %v, %v", tree, err)
+	}
+
+	c.Apply(Message{BeginRendering: &BeginRendering{SurfaceID: "empty", Root: "root"}})
+	if _, err := c.Render("empty"); err == nil || !strings.Contains(err.Error(), "no root component 'root'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := strings.Join(c.SurfaceIDs(), ","); got != "old,empty" {
+		t.Errorf("unexpected surfaces: %s", got)
+	}
+
+	c.Apply(Message{DeleteSurface: &DeleteSurface{SurfaceID: "old"}})
+	if _, ok := c.Component("old", "page"); ok || len(c.Components("old")) != 0 {
+		t.Error("expected deleted surface to be forgotten")
+	}
+	if _, err := c.Render("old"); err == nil || err.Error() != "a2ui: client: unknown surface 'old'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestClientStateErrors(t *testing.T) {
+	c := NewClientState()
+	if err := c.Apply(Message{}); err == nil {
+		t.Error("expected error for an empty message")
+	}
+	err := c.ApplyAll([]Message{{DataModelPatch: &DataModelPatch{SurfaceID: "s", Patch: []PatchOp{{Op: PatchRemove, Path: "/x"}}}}})
+	if err == nil || !strings.HasPrefix(err.Error(), "a2ui: client: message 0: a2ui: client: surface 's': ") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c.ApplyAll(NewSurface("loop").Add(Card("root", "inner")).Add(Card("inner", "root")).Messages())
+	if _, err := c.Render("loop"); err == nil || err.Error() != "a2ui: client: cycle at component 'root'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
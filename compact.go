@@ -0,0 +1,102 @@
+package a2ui
+
+import "fmt"
+
+// Compact returns the shortest message sequence that leaves a client in
+// the same state as messages: per surface, in order of first appearance,
+// one BeginRendering, one UpdateComponents with the final definition of
+// every component and one DataModelUpdate with the final data. Use it to
+// bootstrap late-joining clients or to cache a session's output.
+//
+// Later components replace earlier ones with the same ID in place, data
+// updates and patches are applied in order, and a DeleteSurface discards
+// everything before it, so a surface deleted at the end is omitted.
+// Messages a surface never received are not invented: without a
+// BeginRendering none is emitted. If BeginRendering first came after the
+// components, as in ProtocolV08, it is kept last.
+func Compact(messages []Message) ([]Message, error) {
+	var order []string
+	states := make(map[string]*compactState)
+	for i, msg := range messages {
+		if err := msg.Validate(); err != nil {
+			return nil, fmt.Errorf("a2ui: compact: message %d: %w", i, err)
+		}
+		id := msg.SurfaceID()
+		st, ok := states[id]
+		if !ok {
+			st = newCompactState()
+			states[id] = st
+			order = append(order, id)
+		}
+		if err := st.apply(msg); err != nil {
+			return nil, fmt.Errorf("a2ui: compact: message %d: %w", i, err)
+		}
+	}
+
+	var out []Message
+	for _, id := range order {
+		out = append(out, states[id].messages(id)...)
+	}
+	return out, nil
+}
+
+// compactState accumulates the final state of one surface.
+type compactState struct {
+	begin      *BeginRendering
+	beginLast  bool
+	components []any
+	index      map[string]int
+	data       *DataModel
+}
+
+func newCompactState() *compactState {
+	return &compactState{index: make(map[string]int), data: NewDataModel()}
+}
+
+func (st *compactState) apply(msg Message) error {
+	switch msg.Kind() {
+	case MessageKindBeginRendering:
+		if st.begin == nil {
+			st.beginLast = len(st.components) > 0
+		}
+		st.begin = msg.BeginRendering
+	case MessageKindUpdateComponents:
+		for _, c := range msg.UpdateComponents.Components {
+			id := componentID(c)
+			if i, ok := st.index[id]; ok {
+				st.components[i] = c
+				continue
+			}
+			st.index[id] = len(st.components)
+			st.components = append(st.components, c)
+		}
+	case MessageKindDataModelUpdate:
+		return st.data.Apply(msg.DataModelUpdate)
+	case MessageKindDataModelPatch:
+		return st.data.ApplyPatch(msg.DataModelPatch.Patch)
+	case MessageKindDeleteSurface:
+		*st = *newCompactState()
+	}
+	return nil
+}
+
+func (st *compactState) messages(id string) []Message {
+	var out []Message
+	if st.begin != nil && !st.beginLast {
+		out = append(out, Message{BeginRendering: st.begin})
+	}
+	if len(st.components) > 0 {
+		out = append(out, Message{
+			UpdateComponents: &UpdateComponents{SurfaceID: id, Components: st.components},
+		})
+	}
+	if !st.data.Empty() {
+		out = append(out, Message{
+			DataModelUpdate: &DataModelUpdate{SurfaceID: id, Contents: st.data.Contents()},
+		})
+	}
+	if st.begin != nil && st.beginLast {
+		out = append(out, Message{BeginRendering: st.begin})
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package a2ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	s := NewSurface("main")
+	s.SetDataPatches(true)
+	s.Add(Column("root", "title"))
+	s.Add(TextStatic("title", "Loading"))
+	s.SetData("/user/name", "Ada")
+	msgs := s.Messages()
+
+	other := NewSurface("other").Add(TextStatic("root", "Temp"))
+	msgs = append(msgs, other.Messages()...)
+
+	s.Add(TextStatic("title", "Done"))
+	s.Add(TextStatic("extra", "More"))
+	s.Add(Column("root", "title", "extra"))
+	s.SetData("/user/name", "Grace")
+	s.SetData("/items", []any{"a"})
+	s.Batch(func(b *Batch) { b.Data().Append("/items", "b") })
+	msgs = append(msgs, s.Flush()...)
+	msgs = append(msgs, Message{DeleteSurface: &DeleteSurface{SurfaceID: "other"}})
+	other.Add(TextStatic("root", "Back"))
+	msgs = append(msgs, other.Messages()[1])
+
+	got, err := Compact(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds := messageKinds(got); kinds != "main:beginRendering main:updateComponents main:dataModelUpdate other:updateComponents" {
+		t.Fatalf("unexpected messages: %s", kinds)
+	}
+	if !reflect.DeepEqual(got[1].UpdateComponents.Components, s.UpdateComponentsMessage().UpdateComponents.Components) {
+		t.Errorf("expected final components in insertion order, got %v", got[1].UpdateComponents.Components)
+	}
+	want := map[string]any{"/user/name": "Grace", "/items": []any{"a", "b"}}
+	if !reflect.DeepEqual(got[2].DataModelUpdate.Contents, want) {
+		t.Errorf("expected final data, got %v", got[2].DataModelUpdate.Contents)
+	}
+	if c := got[3].UpdateComponents.Components; len(c) != 1 || c[0].(Component).Text != "Back" {
+		t.Errorf("expected state after DeleteSurface only, got %v", c)
+	}
+}
+
+func TestCompactV08Order(t *testing.T) {
+	s := NewSurface("s").SetProtocol(ProtocolV08).Add(TextStatic("root", "Hi"))
+	msgs := append(s.Messages(), s.Add(TextStatic("root", "Bye")).Flush()...)
+	got, err := Compact(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds := messageKinds(got); kinds != "s:updateComponents s:beginRendering" {
+		t.Errorf("expected BeginRendering to stay last, got %s", kinds)
+	}
+}
+
+func TestCompactErrors(t *testing.T) {
+	_, err := Compact([]Message{{}})
+	if err == nil || err.Error() != "a2ui: compact: message 0: a2ui: message has no payload" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = Compact([]Message{{DataModelPatch: &DataModelPatch{SurfaceID: "s", Patch: []PatchOp{{Op: PatchRemove, Path: "/missing"}}}}})
+	if err == nil || !strings.HasPrefix(err.Error(), "a2ui: compact: message 0: a2ui: patch op 0") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package a2ui
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// These tests are meant to be run with -race.
+
+func TestSurfaceConcurrentWriters(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root"))
+
+	const workers, cards = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < cards; i++ {
+				id := fmt.Sprintf("card-%d-%d", w, i)
+				s.Add(Card(id, id+"-text"))
+				s.Add(TextBound(id+"-text", "/cards/"+id))
+				s.SetData("/cards/"+id, i)
+				s.Get(id)
+				s.Validate()
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < cards; i++ {
+				s.PendingUpdates()
+				s.Components()
+				s.Depth()
+				s.ValidateBindings()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(s.Components()); n != 1+2*workers*cards {
+		t.Errorf("expected %d components, got %d", 1+2*workers*cards, n)
+	}
+	if contents := s.DataModelUpdateMessage().DataModelUpdate.Contents; len(contents) != workers*cards {
+		t.Errorf("expected %d data paths, got %d", workers*cards, len(contents))
+	}
+}
+
+func TestSurfaceBatchIsAtomic(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root"))
+	s.Flush()
+
+	const batches = 100
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < batches; i++ {
+			id := fmt.Sprintf("card-%d", i)
+			s.Batch(func(b *Batch) {
+				b.Add(Card(id, id+"-text"))
+				b.Add(TextBound(id+"-text", "/"+id))
+				b.SetData("/"+id, "x")
+				b.Data().Append("/ids", id)
+			})
+		}
+	}()
+
+	// Every flush sees whole batches: two components per data path
+	flushed := 0
+	check := func(msgs []Message) {
+		var components, paths int
+		for _, msg := range msgs {
+			switch msg.Kind() {
+			case MessageKindUpdateComponents:
+				components += len(msg.UpdateComponents.Components)
+			case MessageKindDataModelUpdate:
+				for path := range msg.DataModelUpdate.Contents {
+					if path != "/ids" {
+						paths++
+					}
+				}
+			}
+		}
+		if components != 2*paths {
+			t.Errorf("flush observed a partial batch: %d components, %d data paths", components, paths)
+		}
+		flushed += paths
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		check(s.Flush())
+	}
+	check(s.Flush())
+
+	if flushed != batches {
+		t.Errorf("expected %d batches flushed, got %d", batches, flushed)
+	}
+}
+
+func TestSurfaceMessagesSnapshot(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root"))
+	s.SetData("/items", []any{"a"})
+	msgs := s.Messages()
+
+	s.Batch(func(b *Batch) { b.Data().Append("/items", "b") })
+	s.Add(TextStatic("extra", "x"))
+	if items := msgs[2].DataModelUpdate.Contents["/items"]; len(items.([]any)) != 1 {
+		t.Errorf("expected snapshot data, got %v", items)
+	}
+	if len(msgs[1].UpdateComponents.Components) != 1 {
+		t.Errorf("expected snapshot components, got %v", msgs[1].UpdateComponents.Components)
+	}
+}
+
+func TestSurfaceDataIsACopy(t *testing.T) {
+	s := NewSurface("test")
+	s.SetData("/items", []any{"a"})
+	s.Flush()
+
+	data := s.Data()
+	data.Append("/items", "b")
+	if items, _ := s.Data().Get("/items"); len(items.([]any)) != 1 {
+		t.Errorf("expected the surface data unchanged, got %v", items)
+	}
+	if msgs := s.PendingUpdates(); len(msgs) != 0 {
+		t.Errorf("expected no pending updates, got %s", messageKinds(msgs))
+	}
+}
@@ -0,0 +1,598 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DataModel is a surface's data tree addressed by JSON Pointers (RFC 6901).
+//
+// Objects are stored as map[string]any and arrays as []any; other values
+// are kept as given until a path descends into them, at which point they
+// are converted to their generic JSON form. Containers are copied on
+// write, so values returned by Get and Contents are not affected by later
+// changes.
+//
+// The model tracks which paths changed since the last call to
+// ResetChanges so that surfaces can send incremental updates. With
+// SetRecording it also records the changes as patch operations.
+type DataModel struct {
+	root    map[string]any
+	changed map[string]bool
+	removed map[string]bool
+
+	recording bool
+	patch     []PatchOp
+}
+
+// NewDataModel creates an empty data model.
+func NewDataModel() *DataModel {
+	return &DataModel{
+		root:    make(map[string]any),
+		changed: make(map[string]bool),
+		removed: make(map[string]bool),
+	}
+}
+
+// Get returns the value at path. The empty pointer returns the whole
+// tree; "/" is the key "".
+func (m *DataModel) Get(path string) (any, bool) {
+	return lookupPointer(m.root, splitPointer(path))
+}
+
+// Empty reports whether the model holds no data.
+func (m *DataModel) Empty() bool {
+	return len(m.root) == 0
+}
+
+// Set stores value at path, creating intermediate objects as needed.
+// Array elements are addressed by index; the index equal to the array
+// length or "-" appends. Setting the root requires an object value.
+func (m *DataModel) Set(path string, value any) error {
+	tokens := splitPointer(path)
+	old, existed := m.Get(path)
+	if existed && reflect.DeepEqual(old, value) {
+		return nil
+	}
+	added := m.missingPrefix(tokens)
+	if err := m.set(tokens, value, false); err != nil {
+		return fmt.Errorf("a2ui: set data %q: %w", path, err)
+	}
+
+	if existed {
+		m.record(PatchOp{Op: PatchReplace, Path: joinPointer(tokens), Value: value})
+	} else {
+		m.record(PatchOp{Op: PatchAdd, Path: joinPointer(added), Value: m.added(added)})
+	}
+	return nil
+}
+
+// Append adds value to the end of the array at path, creating the array
+// if it does not exist.
+func (m *DataModel) Append(path string, value any) error {
+	if _, ok := m.Get(path); !ok {
+		return m.Set(path, []any{value})
+	}
+	return m.Set(joinPointer(append(splitPointer(path), "-")), value)
+}
+
+// Delete removes the value at path. Deleting an array element shifts the
+// following elements down. Deleting the root clears the model. It reports
+// whether a value was removed.
+func (m *DataModel) Delete(path string) bool {
+	tokens := splitPointer(path)
+	if !m.remove(tokens) {
+		return false
+	}
+	if len(tokens) == 0 {
+		m.record(PatchOp{Op: PatchReplace, Path: "", Value: map[string]any{}})
+	} else {
+		m.record(PatchOp{Op: PatchRemove, Path: joinPointer(tokens)})
+	}
+	return true
+}
+
+// Move moves the value at from to path. Moving into an array index
+// inserts before the element at that index.
+func (m *DataModel) Move(from, path string) error {
+	if err := m.move(splitPointer(from), splitPointer(path)); err != nil {
+		return fmt.Errorf("a2ui: move data %q to %q: %w", from, path, err)
+	}
+	m.record(PatchOp{Op: PatchMove, From: joinPointer(splitPointer(from)), Path: joinPointer(splitPointer(path))})
+	return nil
+}
+
+// set stores value at tokens and tracks the change. With insert, an array
+// index inserts instead of replacing.
+func (m *DataModel) set(tokens []string, value any, insert bool) error {
+	if len(tokens) == 0 {
+		obj, ok := asObject(value)
+		if !ok {
+			return fmt.Errorf("data root must be an object, got %T", value)
+		}
+		m.recordRemoved(nil)
+		m.root = copyContainer(obj).(map[string]any)
+		m.markChanged(nil)
+		return nil
+	}
+
+	updated, err := setIn(m.root, tokens, value, insert)
+	if err != nil {
+		return err
+	}
+	changed := m.leafTokens(tokens)
+	m.recordRemoved(changed)
+	m.root = updated.(map[string]any)
+	m.markChanged(changed)
+	return nil
+}
+
+// remove deletes the value at tokens and tracks the change.
+func (m *DataModel) remove(tokens []string) bool {
+	if _, ok := m.Get(joinPointer(tokens)); !ok {
+		return false
+	}
+	if len(tokens) == 0 {
+		m.recordRemoved(nil)
+		m.root = make(map[string]any)
+		return true
+	}
+
+	updated, err := deleteIn(m.root, tokens)
+	if err != nil {
+		return false
+	}
+	changed := m.leafTokens(tokens)
+	m.recordRemoved(changed)
+	m.root = updated.(map[string]any)
+	m.markChanged(changed)
+	return true
+}
+
+func (m *DataModel) move(from, to []string) error {
+	if len(from) == 0 {
+		return errors.New("cannot move the root")
+	}
+	value, ok := m.Get(joinPointer(from))
+	if !ok {
+		return fmt.Errorf("no value at %q", joinPointer(from))
+	}
+	if joinPointer(from) == joinPointer(to) {
+		return nil
+	}
+	if isPointerPrefix(joinPointer(from), joinPointer(to)) {
+		return errors.New("cannot move a value into itself")
+	}
+
+	// Check the target before changing anything
+	without, err := deleteIn(m.root, from)
+	if err != nil {
+		return err
+	}
+	if _, err := setIn(without, to, value, true); err != nil {
+		return err
+	}
+	m.remove(from)
+	return m.set(to, value, true)
+}
+
+// missingPrefix returns the shortest prefix of tokens that does not exist
+// yet; a Set at tokens adds the value there.
+func (m *DataModel) missingPrefix(tokens []string) []string {
+	for k := 1; k <= len(tokens); k++ {
+		if _, ok := m.Get(joinPointer(tokens[:k])); !ok {
+			return tokens[:k]
+		}
+	}
+	return tokens
+}
+
+// added returns the value at tokens after an add, resolving a trailing
+// "-" to the appended element.
+func (m *DataModel) added(tokens []string) any {
+	if len(tokens) == 0 || tokens[len(tokens)-1] != "-" {
+		v, _ := m.Get(joinPointer(tokens))
+		return v
+	}
+	parent, _ := m.Get(joinPointer(tokens[:len(tokens)-1]))
+	if arr, ok := asContainer(parent); ok {
+		if a, ok := arr.([]any); ok && len(a) > 0 {
+			return a[len(a)-1]
+		}
+	}
+	return nil
+}
+
+// Merge deep-merges value into the object at path: keys of nested objects
+// are merged recursively and all other values replace what is there.
+// Merging into a missing path or a non-object behaves like Set.
+func (m *DataModel) Merge(path string, value any) error {
+	obj, ok := asObject(value)
+	if !ok {
+		return m.Set(path, value)
+	}
+	if existing, exists := m.Get(path); !exists || !isObject(existing) {
+		return m.Set(path, value)
+	}
+
+	tokens := splitPointer(path)
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := append(append([]string{}, tokens...), k)
+		if err := m.Merge(joinPointer(child), obj[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies a DataModelUpdate, setting each path in its contents.
+// Null values delete the path.
+func (m *DataModel) Apply(u *DataModelUpdate) error {
+	for _, path := range sortedPaths(u.Contents) {
+		value := u.Contents[path]
+		if value == nil {
+			m.Delete(path)
+			continue
+		}
+		if err := m.Set(path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Contents returns the whole model as DataModelUpdate contents: a map from
+// JSON Pointer to value with objects flattened to their leaf paths. Arrays
+// and scalars are leaves, and empty objects are kept as {}.
+func (m *DataModel) Contents() map[string]any {
+	contents := make(map[string]any)
+	if len(m.root) > 0 {
+		flatten(contents, nil, m.root)
+	}
+	return contents
+}
+
+// Changes returns the contents for paths changed since the last call to
+// ResetChanges, in the same flattened form as Contents. Leaf paths that
+// no longer exist map to nil.
+func (m *DataModel) Changes() map[string]any {
+	contents := make(map[string]any)
+	for path := range m.changed {
+		if value, ok := m.Get(path); ok {
+			flatten(contents, splitPointer(path), value)
+		}
+	}
+	for path := range m.removed {
+		if _, ok := contents[path]; !ok {
+			if _, exists := m.Get(path); !exists {
+				contents[path] = nil
+			}
+		}
+	}
+	return contents
+}
+
+// HasChanges reports whether any path changed since the last ResetChanges.
+func (m *DataModel) HasChanges() bool {
+	return len(m.changed) > 0 || len(m.removed) > 0
+}
+
+// ResetChanges marks all changes as sent and clears the recorded patch.
+func (m *DataModel) ResetChanges() {
+	m.changed = make(map[string]bool)
+	m.removed = make(map[string]bool)
+	m.patch = nil
+}
+
+// Clone returns a deep copy of the model, including its changed paths.
+func (m *DataModel) Clone() *DataModel {
+	c := &DataModel{
+		root:    deepCopy(m.root).(map[string]any),
+		changed: make(map[string]bool, len(m.changed)),
+		removed: make(map[string]bool, len(m.removed)),
+	}
+	for path := range m.changed {
+		c.changed[path] = true
+	}
+	for path := range m.removed {
+		c.removed[path] = true
+	}
+	c.recording = m.recording
+	c.patch = append([]PatchOp(nil), m.patch...)
+	return c
+}
+
+// MarshalJSON encodes the model as its nested JSON tree.
+func (m *DataModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.root)
+}
+
+// markChanged records a changed path. Changes below an already changed
+// path are covered by it; a change to a parent replaces its descendants.
+func (m *DataModel) markChanged(tokens []string) {
+	path := joinPointer(tokens)
+	for p := range m.changed {
+		if isPointerPrefix(p, path) {
+			return
+		}
+		if isPointerPrefix(path, p) {
+			delete(m.changed, p)
+		}
+	}
+	m.changed[path] = true
+}
+
+// recordRemoved remembers the current leaf paths at or below tokens so
+// that Changes can report the ones a change removes.
+func (m *DataModel) recordRemoved(tokens []string) {
+	old, ok := m.Get(joinPointer(tokens))
+	if !ok {
+		return
+	}
+	leaves := make(map[string]any)
+	flatten(leaves, tokens, old)
+	for path := range leaves {
+		m.removed[path] = true
+	}
+}
+
+// leafTokens truncates tokens at the first array, since arrays are leaves
+// in flattened contents and a change inside one changes the whole array.
+func (m *DataModel) leafTokens(tokens []string) []string {
+	var node any = m.root
+	for i, token := range tokens {
+		container, ok := asContainer(node)
+		if !ok {
+			return tokens[:i]
+		}
+		if _, isArray := container.([]any); isArray {
+			return tokens[:i]
+		}
+		if node, ok = container.(map[string]any)[token]; !ok {
+			return tokens
+		}
+	}
+	return tokens
+}
+
+// isPointerPrefix reports whether pointer a equals b or is an ancestor of b.
+func isPointerPrefix(a, b string) bool {
+	if a == "" || a == b {
+		return true
+	}
+	return strings.HasPrefix(b, a+"/")
+}
+
+// lookupPointer returns the value at tokens below node.
+func lookupPointer(node any, tokens []string) (any, bool) {
+	for _, token := range tokens {
+		container, ok := asContainer(node)
+		if !ok {
+			return nil, false
+		}
+		switch c := container.(type) {
+		case map[string]any:
+			if node, ok = c[token]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, ok := arrayIndex(token, len(c))
+			if !ok || i == len(c) {
+				return nil, false
+			}
+			node = c[i]
+		}
+	}
+	return node, true
+}
+
+// setIn returns a copy of node with value stored at tokens. With insert,
+// a final array index inserts instead of replacing.
+func setIn(node any, tokens []string, value any, insert bool) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	if node == nil {
+		node = map[string]any{}
+	}
+	container, ok := asContainer(node)
+	if !ok {
+		return nil, fmt.Errorf("cannot descend into %T at %q", node, tokens[0])
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch c := copyContainer(container).(type) {
+	case map[string]any:
+		child, err := setIn(c[token], rest, value, insert)
+		if err != nil {
+			return nil, err
+		}
+		c[token] = child
+		return c, nil
+
+	case []any:
+		i, ok := arrayIndex(token, len(c))
+		if !ok {
+			return nil, fmt.Errorf("invalid array index %q", token)
+		}
+		if len(rest) == 0 && insert && i < len(c) {
+			c = append(c[:i], append([]any{value}, c[i:]...)...)
+			return c, nil
+		}
+		if i == len(c) {
+			c = append(c, nil)
+		}
+		child, err := setIn(c[i], rest, value, insert)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, fmt.Errorf("cannot descend into %T", node)
+}
+
+// deleteIn returns a copy of node with the value at tokens removed.
+func deleteIn(node any, tokens []string) (any, error) {
+	container, ok := asContainer(node)
+	if !ok {
+		return nil, fmt.Errorf("cannot descend into %T", node)
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch c := copyContainer(container).(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			delete(c, token)
+			return c, nil
+		}
+		child, err := deleteIn(c[token], rest)
+		if err != nil {
+			return nil, err
+		}
+		c[token] = child
+		return c, nil
+
+	case []any:
+		i, ok := arrayIndex(token, len(c))
+		if !ok || i == len(c) {
+			return nil, fmt.Errorf("invalid array index %q", token)
+		}
+		if len(rest) == 0 {
+			return append(c[:i], c[i+1:]...), nil
+		}
+		child, err := deleteIn(c[i], rest)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, fmt.Errorf("cannot descend into %T", node)
+}
+
+// asContainer returns node as map[string]any or []any, converting other
+// maps, slices, arrays and structs to their generic JSON form.
+func asContainer(node any) (any, bool) {
+	switch node.(type) {
+	case map[string]any, []any:
+		return node, true
+	case nil:
+		return nil, false
+	}
+	switch reflect.Indirect(reflect.ValueOf(node)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+	default:
+		return nil, false
+	}
+	generic, err := toGeneric(node)
+	if err != nil {
+		return nil, false
+	}
+	switch generic.(type) {
+	case map[string]any, []any:
+		return generic, true
+	}
+	return nil, false
+}
+
+// copyContainer returns a shallow copy of a map[string]any or []any.
+func copyContainer(c any) any {
+	switch v := c.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = item
+		}
+		return out
+	case []any:
+		return append([]any(nil), v...)
+	}
+	return c
+}
+
+// deepCopy copies generic containers recursively. Leaves are shared.
+func deepCopy(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(c))
+		for k, item := range c {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(c))
+		for i, item := range c {
+			out[i] = deepCopy(item)
+		}
+		return out
+	}
+	return v
+}
+
+// arrayIndex parses an RFC 6901 array index for an array of length n.
+// "-" and n both address the position after the last element.
+func arrayIndex(token string, n int) (int, bool) {
+	if token == "-" {
+		return n, true
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > n {
+		return 0, false
+	}
+	return i, true
+}
+
+// flatten writes value into contents at tokens, descending into objects
+// so that only leaf paths are emitted. Structs and other maps count as
+// objects; arrays and slices are leaves and keep their Go type.
+func flatten(contents map[string]any, tokens []string, value any) {
+	obj, ok := asObject(value)
+	if !ok || len(obj) == 0 {
+		if ok {
+			value = map[string]any{}
+		}
+		contents[joinPointer(tokens)] = value
+		return
+	}
+	for k, item := range obj {
+		flatten(contents, append(append([]string{}, tokens...), k), item)
+	}
+}
+
+func isObject(value any) bool {
+	_, ok := asObject(value)
+	return ok
+}
+
+// asObject returns value as map[string]any if it encodes as a JSON object.
+func asObject(value any) (map[string]any, bool) {
+	if value == nil {
+		return nil, false
+	}
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Map, reflect.Struct:
+		c, ok := asContainer(value)
+		obj, isObj := c.(map[string]any)
+		return obj, ok && isObj
+	}
+	return nil, false
+}
@@ -0,0 +1,199 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDataModelSetGet(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/user", map[string]any{"name": "Alice", "age": 30})
+	m.Set("/user/email", "alice@example.com")
+
+	user, ok := m.Get("/user")
+	if !ok {
+		t.Fatal("expected /user")
+	}
+	want := map[string]any{"name": "Alice", "age": 30, "email": "alice@example.com"}
+	if !reflect.DeepEqual(user, want) {
+		t.Errorf("expected merged user object, got %v", user)
+	}
+	if v, _ := m.Get("/user/name"); v != "Alice" {
+		t.Errorf("expected 'Alice', got %v", v)
+	}
+	if _, ok := m.Get("/missing/path"); ok {
+		t.Error("expected missing path to be absent")
+	}
+}
+
+func TestDataModelPointerEscaping(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/a~1b/c~0d", 1)
+
+	root, _ := m.Get("")
+	inner, ok := root.(map[string]any)["a/b"].(map[string]any)
+	if !ok || inner["c~d"] != 1 {
+		t.Errorf("expected unescaped keys 'a/b' and 'c~d', got %v", root)
+	}
+	if _, ok := m.Contents()["/a~1b/c~0d"]; !ok {
+		t.Errorf("expected escaped path in contents, got %v", m.Contents())
+	}
+}
+
+func TestDataModelEmptyKey(t *testing.T) {
+	m := NewDataModel().SetRecording(true)
+	m.Set("/", "empty key")
+	m.Set("/a/", 1)
+
+	root, _ := m.Get("")
+	if obj := root.(map[string]any); obj[""] != "empty key" || obj["a"].(map[string]any)[""] != 1 {
+		t.Errorf("expected \"/\" to address the key \"\", got %v", root)
+	}
+	if v, _ := m.Get("/"); v != "empty key" {
+		t.Errorf("expected Get(\"/\") to return the key \"\", got %v", v)
+	}
+
+	m.ResetChanges()
+	m.Delete("")
+	if patch := m.Patch(); len(patch) != 1 || patch[0].Path != "" {
+		t.Errorf("expected root replace with path \"\", got %+v", patch)
+	}
+}
+
+func TestDataModelArrays(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/items", []string{"a", "b"})
+	m.Set("/items/-", "c")
+	m.Set("/items/3", "d")
+	m.Set("/items/0", "A")
+
+	items, _ := m.Get("/items")
+	if !reflect.DeepEqual(items, []any{"A", "b", "c", "d"}) {
+		t.Errorf("unexpected items: %v", items)
+	}
+
+	if err := m.Set("/items/9", "x"); err == nil {
+		t.Error("expected error for index past the end")
+	}
+	if err := m.Set("/items/01", "x"); err == nil {
+		t.Error("expected error for index with leading zero")
+	}
+	if err := m.Set("/items/0/name", "x"); err == nil {
+		t.Error("expected error when descending into a string")
+	}
+
+	m.Delete("/items/1")
+	items, _ = m.Get("/items")
+	if !reflect.DeepEqual(items, []any{"A", "c", "d"}) {
+		t.Errorf("expected elements to shift after delete, got %v", items)
+	}
+}
+
+func TestDataModelDeleteAndMerge(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/user", map[string]any{"name": "Alice", "prefs": map[string]any{"theme": "dark", "lang": "en"}})
+
+	if !m.Delete("/user/name") || m.Delete("/user/name") {
+		t.Error("expected Delete to report whether a value was removed")
+	}
+
+	m.Merge("/user", map[string]any{"prefs": map[string]any{"theme": "light"}, "role": "admin"})
+	user, _ := m.Get("/user")
+	want := map[string]any{"role": "admin", "prefs": map[string]any{"theme": "light", "lang": "en"}}
+	if !reflect.DeepEqual(user, want) {
+		t.Errorf("unexpected merge result: %v", user)
+	}
+
+	// Merging into a non-object replaces it
+	m.Set("/flag", true)
+	m.Merge("/flag", map[string]any{"on": true})
+	if v, _ := m.Get("/flag/on"); v != true {
+		t.Errorf("expected merge to replace scalar, got %v", v)
+	}
+}
+
+func TestDataModelContentsFlattened(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/user", map[string]any{"name": "Alice", "tags": []any{"x"}, "empty": map[string]any{}})
+	m.Set("/count", 3)
+
+	want := map[string]any{
+		"/user/name":  "Alice",
+		"/user/tags":  []any{"x"},
+		"/user/empty": map[string]any{},
+		"/count":      3,
+	}
+	if got := m.Contents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDataModelChanges(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/user", map[string]any{"name": "Alice", "age": 30})
+	m.Set("/items", []any{"a"})
+	m.ResetChanges()
+
+	m.Set("/user/name", "Alice") // unchanged
+	if m.HasChanges() {
+		t.Fatalf("expected no changes, got %v", m.Changes())
+	}
+
+	m.Set("/user", map[string]any{"name": "Bob"}) // drops /user/age
+	m.Set("/items/-", "b")                        // arrays change as a whole
+
+	want := map[string]any{
+		"/user/name": "Bob",
+		"/user/age":  nil,
+		"/items":     []any{"a", "b"},
+	}
+	if got := m.Changes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDataModelCopyOnWrite(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/user/name", "Alice")
+	before := m.Contents()
+	user, _ := m.Get("/user")
+
+	m.Set("/user/name", "Bob")
+	if before["/user/name"] != "Alice" || user.(map[string]any)["name"] != "Alice" {
+		t.Error("expected earlier results to be unaffected by later changes")
+	}
+}
+
+func TestDataModelApplyAndJSON(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/old", 1)
+	err := m.Apply(&DataModelUpdate{Contents: map[string]any{
+		"/user/name": "Alice",
+		"/old":       nil,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"user":{"name":"Alice"}}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestSurfaceSetDataNested(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(TextStatic("root", "Hi"))
+	s.SetData("/user", map[string]any{"name": "Alice"})
+	s.SetData("/user/name", "Bob")
+
+	msgs := s.Messages()
+	contents := msgs[2].DataModelUpdate.Contents
+	if len(contents) != 1 || contents["/user/name"] != "Bob" {
+		t.Errorf("expected single resolved path, got %v", contents)
+	}
+}
@@ -0,0 +1,235 @@
+package a2ui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Decoder reads A2UI messages from a JSON Lines stream.
+type Decoder struct {
+	r        *bufio.Reader
+	registry *Registry
+	line     int
+}
+
+// NewDecoder creates a decoder reading from r that uses DefaultRegistry.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), registry: DefaultRegistry}
+}
+
+// SetRegistry sets the registry used to decode components.
+func (d *Decoder) SetRegistry(r *Registry) *Decoder {
+	d.registry = r
+	return d
+}
+
+// DecodeError reports a malformed line in a message stream.
+type DecodeError struct {
+	Line int
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decode reads the next message from the stream. Blank lines are skipped.
+// Components in UpdateComponents are decoded into their registered types.
+// It returns io.EOF when the stream ends.
+func (d *Decoder) Decode() (Message, error) {
+	for {
+		data, err := d.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return Message{}, err
+		}
+		d.line++
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Message{}, &DecodeError{Line: d.line, Err: err}
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		msg, derr := decodeMessage(data, d.registry)
+		if derr != nil {
+			return Message{}, &DecodeError{Line: d.line, Err: derr}
+		}
+		return msg, nil
+	}
+}
+
+// Line returns the number of the line read by the last call to Decode.
+func (d *Decoder) Line() int {
+	return d.line
+}
+
+// ReadJSONL reads all messages from a JSON Lines stream.
+func ReadJSONL(r io.Reader) ([]Message, error) {
+	dec := NewDecoder(r)
+	var messages []Message
+	for {
+		msg, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+}
+
+// wireComponents is the payload of updateComponents (and v0.8 surfaceUpdate)
+// with components left undecoded.
+type wireComponents struct {
+	SurfaceID  string            `json:"surfaceId"`
+	Components []json.RawMessage `json:"components"`
+}
+
+// wireDataModel covers dataModelUpdate (default contents map or v0.8
+// contents list) and v0.9 updateDataModel.
+type wireDataModel struct {
+	SurfaceID string          `json:"surfaceId"`
+	Path      string          `json:"path"`
+	Contents  json.RawMessage `json:"contents"`
+	Value     any             `json:"value"`
+}
+
+// decodeMessage decodes a single JSON message in any supported protocol
+// version using reg for components.
+func decodeMessage(data []byte, reg *Registry) (Message, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Message{}, err
+	}
+
+	var msg Message
+	var kinds []MessageKind
+	for key, raw := range fields {
+		if string(raw) == "null" {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "beginRendering":
+			msg.BeginRendering = &BeginRendering{}
+			err = json.Unmarshal(raw, msg.BeginRendering)
+			kinds = append(kinds, MessageKindBeginRendering)
+
+		case "createSurface":
+			var create struct {
+				SurfaceID string `json:"surfaceId"`
+			}
+			err = json.Unmarshal(raw, &create)
+			msg.BeginRendering = &BeginRendering{SurfaceID: create.SurfaceID, Root: "root"}
+			kinds = append(kinds, MessageKindBeginRendering)
+
+		case "updateComponents", "surfaceUpdate":
+			msg.UpdateComponents, err = decodeComponents(raw, reg)
+			kinds = append(kinds, MessageKindUpdateComponents)
+
+		case "dataModelUpdate", "updateDataModel":
+			msg.DataModelUpdate, err = decodeDataModel(raw)
+			kinds = append(kinds, MessageKindDataModelUpdate)
+
+		case "deleteSurface":
+			msg.DeleteSurface = &DeleteSurface{}
+			err = json.Unmarshal(raw, msg.DeleteSurface)
+			kinds = append(kinds, MessageKindDeleteSurface)
+
+		case "dataModelPatch":
+			msg.DataModelPatch = &DataModelPatch{}
+			err = json.Unmarshal(raw, msg.DataModelPatch)
+			kinds = append(kinds, MessageKindDataModelPatch)
+
+		default:
+			continue
+		}
+		if err != nil {
+			return Message{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if len(kinds) != 1 {
+		sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+		return Message{}, &MessageError{Kinds: kinds}
+	}
+	return msg, nil
+}
+
+func decodeComponents(raw json.RawMessage, reg *Registry) (*UpdateComponents, error) {
+	var wire wireComponents
+	if err := json.Unmarshal(raw, &wire); err != nil {
+		return nil, err
+	}
+
+	components := make([]any, 0, len(wire.Components))
+	for i, rc := range wire.Components {
+		flat, err := normalizeComponent(rc)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", i, err)
+		}
+		c, err := reg.Decode(flat)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", i, err)
+		}
+		components = append(components, c)
+	}
+	return &UpdateComponents{SurfaceID: wire.SurfaceID, Components: components}, nil
+}
+
+func decodeDataModel(raw json.RawMessage) (*DataModelUpdate, error) {
+	var wire wireDataModel
+	if err := json.Unmarshal(raw, &wire); err != nil {
+		return nil, err
+	}
+	update := &DataModelUpdate{SurfaceID: wire.SurfaceID, Contents: make(map[string]any)}
+
+	contents := bytes.TrimSpace(wire.Contents)
+	switch {
+	case len(contents) == 0 || string(contents) == "null":
+		// v0.9 updateDataModel
+		update.Contents[wire.Path] = wire.Value
+
+	case contents[0] == '[':
+		// v0.8 key/value entries relative to path
+		var entries []map[string]json.RawMessage
+		if err := json.Unmarshal(contents, &entries); err != nil {
+			return nil, err
+		}
+		var base []string
+		if wire.Path != "/" { // v0.8 writes the root as "/"
+			base = splitPointer(wire.Path)
+		}
+		for _, e := range entries {
+			var key string
+			if err := json.Unmarshal(e["key"], &key); err != nil {
+				return nil, fmt.Errorf("entry key: %w", err)
+			}
+			value, err := fromV08Entry(e)
+			if err != nil {
+				return nil, fmt.Errorf("entry %q: %w", key, err)
+			}
+			tokens := append(append([]string{}, base...), key)
+			update.Contents[joinPointer(tokens)] = value
+		}
+
+	default:
+		if err := json.Unmarshal(contents, &update.Contents); err != nil {
+			return nil, err
+		}
+	}
+	return update, nil
+}
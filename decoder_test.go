@@ -0,0 +1,112 @@
+package a2ui
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderRoundTrip(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "title", "list"))
+	s.Add(TextWithHint("title", "Hello", UsageHintH1))
+	s.Add(ListTemplate("list", "item", "/items"))
+	s.AddAll(ButtonWithData("btn", "Go", "submit", map[string]any{"endpoint": "/go"})...)
+	s.SetData("/items", []any{"a", "b"})
+
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, s.Messages()); err != nil {
+		t.Fatalf("WriteJSONL failed: %v", err)
+	}
+
+	msgs, err := ReadJSONL(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSONL failed: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+
+	if msgs[0].BeginRendering == nil || msgs[0].BeginRendering.Root != "root" {
+		t.Errorf("expected BeginRendering with root 'root', got %+v", msgs[0])
+	}
+
+	comps := msgs[1].UpdateComponents.Components
+	if len(comps) != 5 {
+		t.Fatalf("expected 5 components, got %d", len(comps))
+	}
+	title, ok := comps[1].(Component)
+	if !ok {
+		t.Fatalf("expected Component, got %T", comps[1])
+	}
+	if title.Text != "Hello" || title.UsageHint != UsageHintH1 {
+		t.Errorf("unexpected title component: %+v", title)
+	}
+	btn := comps[3].(Component)
+	if btn.Action == nil || btn.Action.Data["endpoint"] != "/go" {
+		t.Errorf("expected button action data, got %+v", btn.Action)
+	}
+
+	items, ok := msgs[2].DataModelUpdate.Contents["/items"].([]any)
+	if !ok || len(items) != 2 {
+		t.Errorf("expected 2 items, got %v", msgs[2].DataModelUpdate.Contents["/items"])
+	}
+}
+
+func TestDecoderSkipsBlankLines(t *testing.T) {
+	input := "\n" + `{"deleteSurface":{"surfaceId":"a"}}` + "\n\n   \n" + `{"deleteSurface":{"surfaceId":"b"}}`
+
+	msgs, err := ReadJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadJSONL failed: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[1].DeleteSurface.SurfaceID != "b" {
+		t.Errorf("expected last line without newline to decode, got %+v", msgs[1])
+	}
+}
+
+func TestDecoderReportsLineNumber(t *testing.T) {
+	input := `{"deleteSurface":{"surfaceId":"a"}}` + "\n\n" + `{"deleteSurface":` + "\n"
+
+	dec := NewDecoder(strings.NewReader(input))
+	if _, err := dec.Decode(); err != nil {
+		t.Fatalf("first Decode failed: %v", err)
+	}
+
+	_, err := dec.Decode()
+	var derr *DecodeError
+	if !errors.As(err, &derr) {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+	if derr.Line != 3 {
+		t.Errorf("expected line 3, got %d", derr.Line)
+	}
+	if !strings.HasPrefix(derr.Error(), "line 3: ") {
+		t.Errorf("unexpected error text: %s", derr.Error())
+	}
+}
+
+func TestDecoderMalformedComponent(t *testing.T) {
+	input := `{"updateComponents":{"surfaceId":"s","components":[{"id":"a","component":"Text"},{"id":5}]}}`
+
+	_, err := ReadJSONL(strings.NewReader(input))
+	var derr *DecodeError
+	if !errors.As(err, &derr) {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+	if derr.Line != 1 || !strings.Contains(derr.Error(), "component 1") {
+		t.Errorf("expected error for component 1 on line 1, got %v", derr)
+	}
+}
+
+func TestDecoderEOF(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(""))
+	if _, err := dec.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
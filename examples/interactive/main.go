@@ -2,10 +2,11 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +21,14 @@ type Booking struct {
 	Party int
 }
 
+// bookingForm is the event data sent by the submit button.
+type bookingForm struct {
+	Name  string    `a2ui:"name"`
+	Date  time.Time `a2ui:"date,required"`
+	Time  string    `a2ui:"time,required"`
+	Party int       `a2ui:"party"`
+}
+
 var (
 	bookings = make(map[string]Booking)
 	mu       sync.RWMutex
@@ -28,7 +37,14 @@ var (
 func main() {
 	http.HandleFunc("/", handleIndex)
 	http.HandleFunc("/form", handleForm)
-	http.HandleFunc("/submit", handleSubmit)
+
+	// Client events are dispatched by surface, component and event type
+	router := a2ui.NewEventRouter()
+	router.HandleFunc("booking-form", "submit-btn", "action", submitBooking)
+	router.SetNotFound(a2ui.EventHandlerFunc(func(ctx *a2ui.EventContext) ([]a2ui.Message, error) {
+		return errorMessages("Unknown action")
+	}))
+	http.Handle("/submit", withCORS(router))
 
 	fmt.Println("A2UI Interactive Demo")
 	fmt.Println("Open http://localhost:8080 in your browser")
@@ -76,37 +92,40 @@ func handleForm(w http.ResponseWriter, r *http.Request) {
 	a2ui.WriteJSONL(w, surface.Messages())
 }
 
-func handleSubmit(w http.ResponseWriter, r *http.Request) {
-	// CORS headers must be set before any response
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/x-ndjson")
+// withCORS allows the browser page to post events cross-origin.
+func withCORS(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// CORS headers must be set before any response
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
 
-	// Parse client event
-	var clientMsg a2ui.ClientMessage
-	if err := json.NewDecoder(r.Body).Decode(&clientMsg); err != nil {
-		log.Printf("Error decoding: %v", err)
-		sendError(w, "Invalid request")
-		return
+func submitBooking(ctx *a2ui.EventContext) ([]a2ui.Message, error) {
+	log.Printf("Received event: %+v", ctx.Event)
+
+	// Bind form data from the event; party may arrive as a number or a string
+	var form bookingForm
+	if err := ctx.Event.Bind(&form); err != nil {
+		var bindErr *a2ui.BindError
+		if errors.As(err, &bindErr) {
+			fields := make([]string, len(bindErr.Errors))
+			for i, fe := range bindErr.Errors {
+				fields[i] = fe.Field + " " + fe.Message
+			}
+			return errorMessages(strings.Join(fields, ", "))
+		}
+		return nil, err
 	}
 
-	log.Printf("Received event: %+v", clientMsg.Event)
-
-	// Extract form data from event
-	data := clientMsg.Event.Data
-	name, _ := data["name"].(string)
-	date, _ := data["date"].(string)
-	timeStr, _ := data["time"].(string)
-	partyFloat, _ := data["party"].(float64)
-	party := int(partyFloat)
-
+	name, party := form.Name, form.Party
 	if name == "" {
 		name = "Guest"
 	}
@@ -118,8 +137,8 @@ func handleSubmit(w http.ResponseWriter, r *http.Request) {
 	booking := Booking{
 		ID:    fmt.Sprintf("BK-%d", time.Now().Unix()),
 		Name:  name,
-		Date:  date,
-		Time:  timeStr,
+		Date:  form.Date.Format("2006-01-02"),
+		Time:  form.Time,
 		Party: party,
 	}
 
@@ -148,14 +167,14 @@ func handleSubmit(w http.ResponseWriter, r *http.Request) {
 	surface.SetData("/booking/details",
 		fmt.Sprintf("%s - %s at %s for %d guests", booking.Name, booking.Date, booking.Time, booking.Party))
 
-	a2ui.WriteJSONL(w, surface.Messages())
+	return surface.Messages(), nil
 }
 
-func sendError(w http.ResponseWriter, msg string) {
+func errorMessages(msg string) ([]a2ui.Message, error) {
 	surface := a2ui.NewSurface("error")
 	surface.Add(a2ui.Column("root", "error-msg"))
 	surface.Add(a2ui.TextStatic("error-msg", "Error: "+msg))
-	a2ui.WriteJSONL(w, surface.Messages())
+	return surface.Messages(), nil
 }
 
 const indexHTML = `<!DOCTYPE html>
@@ -26,13 +26,11 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 }
 
 func handlePlan(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+	stream := a2ui.NewStream(w, r)
+	defer stream.Close()
+	if err := stream.Err(); err != nil { // e.g. the response cannot flush
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -45,8 +43,7 @@ func handlePlan(w http.ResponseWriter, r *http.Request) {
 	surface.Add(a2ui.TextStatic("loading", "Planning your trip..."))
 	surface.Add(a2ui.TextStatic("footer", ""))
 
-	a2ui.WriteJSONL(w, surface.Messages())
-	flusher.Flush()
+	stream.Open(surface)
 	time.Sleep(800 * time.Millisecond)
 
 	// Step 2: Day 1
@@ -63,12 +60,13 @@ func handlePlan(w http.ResponseWriter, r *http.Request) {
 		{"name": "Welcome dinner at La Terrazza"},
 	})
 
-	// Update content to show day1 instead of loading
+	// Update content to show day1 instead of loading.
+	// Only changed components and data paths are sent.
 	surface.Add(a2ui.Column("content", "day1"))
 
-	a2ui.WriteMessage(w, surface.UpdateComponentsMessage())
-	a2ui.WriteMessage(w, surface.DataModelUpdateMessage())
-	flusher.Flush()
+	if stream.Update() != nil {
+		return // client went away
+	}
 	time.Sleep(1000 * time.Millisecond)
 
 	// Step 3: Day 2
@@ -90,9 +88,9 @@ func handlePlan(w http.ResponseWriter, r *http.Request) {
 	// Update content to show both days
 	surface.Add(a2ui.Column("content", "day1", "day2"))
 
-	a2ui.WriteMessage(w, surface.UpdateComponentsMessage())
-	a2ui.WriteMessage(w, surface.DataModelUpdateMessage())
-	flusher.Flush()
+	if stream.Update() != nil {
+		return // client went away
+	}
 	time.Sleep(1000 * time.Millisecond)
 
 	// Step 4: Day 3
@@ -112,9 +110,9 @@ func handlePlan(w http.ResponseWriter, r *http.Request) {
 
 	surface.Add(a2ui.Column("content", "day1", "day2", "day3"))
 
-	a2ui.WriteMessage(w, surface.UpdateComponentsMessage())
-	a2ui.WriteMessage(w, surface.DataModelUpdateMessage())
-	flusher.Flush()
+	if stream.Update() != nil {
+		return // client went away
+	}
 	time.Sleep(800 * time.Millisecond)
 
 	// Step 5: Summary
@@ -128,9 +126,7 @@ func handlePlan(w http.ResponseWriter, r *http.Request) {
 	surface.Add(a2ui.Column("content", "day1", "day2", "day3", "summary"))
 	surface.Add(a2ui.TextStatic("footer", "Have a great trip!"))
 
-	a2ui.WriteMessage(w, surface.UpdateComponentsMessage())
-	a2ui.WriteMessage(w, surface.DataModelUpdateMessage())
-	flusher.Flush()
+	stream.Update()
 }
 
 const indexHTML = `<!DOCTYPE html>
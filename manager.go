@@ -0,0 +1,203 @@
+package a2ui
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrUnknownSurface is returned for surface IDs a SurfaceManager does not manage.
+	ErrUnknownSurface = errors.New("a2ui: unknown surface")
+	// ErrSurfaceExists is returned when adding a surface ID that is already managed.
+	ErrSurfaceExists = errors.New("a2ui: surface already exists")
+	// ErrSurfaceNotBegun is returned when updating a surface the client has
+	// not been sent BeginRendering for.
+	ErrSurfaceNotBegun = errors.New("a2ui: surface not begun")
+)
+
+// SurfaceManager tracks the surfaces a client has. Surfaces are created or
+// added by ID, begun once their full state has been sent, updated with
+// their pending changes and deleted with a DeleteSurface message.
+//
+// The manager only returns messages; sending them is up to the caller. It
+// never returns UpdateComponents or data messages for a surface before its
+// BeginRendering. A SurfaceManager is safe for concurrent use.
+type SurfaceManager struct {
+	mu       sync.Mutex
+	protocol ProtocolVersion
+	surfaces map[string]*Surface
+	begun    map[string]bool
+	order    []string
+}
+
+// NewSurfaceManager creates an empty surface manager.
+func NewSurfaceManager() *SurfaceManager {
+	return &SurfaceManager{
+		surfaces: make(map[string]*Surface),
+		begun:    make(map[string]bool),
+	}
+}
+
+// SetProtocol sets the protocol version of surfaces created with Create.
+func (m *SurfaceManager) SetProtocol(v ProtocolVersion) *SurfaceManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.protocol = v
+	return m
+}
+
+// Create creates and adds a surface with the given ID.
+func (m *SurfaceManager) Create(id string) (*Surface, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := NewSurface(id).SetProtocol(m.protocol)
+	if err := m.add(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add adds an existing surface. It is not begun, even if its messages
+// were sent before.
+func (m *SurfaceManager) Add(s *Surface) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.add(s)
+}
+
+func (m *SurfaceManager) add(s *Surface) error {
+	id := s.ID()
+	if _, ok := m.surfaces[id]; ok {
+		return fmt.Errorf("%w: '%s'", ErrSurfaceExists, id)
+	}
+	m.surfaces[id] = s
+	m.order = append(m.order, id)
+	return nil
+}
+
+// Get returns the surface with the given ID.
+func (m *SurfaceManager) Get(id string) (*Surface, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.surfaces[id]
+	return s, ok
+}
+
+// IDs returns the IDs of all managed surfaces in the order they were added.
+func (m *SurfaceManager) IDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, len(m.order))
+	copy(ids, m.order)
+	return ids
+}
+
+// Begun reports whether BeginRendering has been returned for the surface.
+func (m *SurfaceManager) Begun(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.begun[id]
+}
+
+// Begin returns the surface's full message sequence, including
+// BeginRendering, and marks it begun. Calling it again resends the full
+// state, for example after a client reconnects.
+func (m *SurfaceManager) Begin(id string) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.surfaces[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownSurface, id)
+	}
+	m.begun[id] = true
+	return s.Messages(), nil
+}
+
+// Update returns the surface's pending changes (see Surface.Flush). It
+// fails with ErrSurfaceNotBegun if the surface has not been begun.
+func (m *SurfaceManager) Update(id string) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.surfaces[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownSurface, id)
+	}
+	if !m.begun[id] {
+		return nil, fmt.Errorf("%w: '%s'", ErrSurfaceNotBegun, id)
+	}
+	return s.Flush(), nil
+}
+
+// Flush returns the messages that bring the client up to date with all
+// surfaces, in the order they were added: the full state of surfaces not
+// yet begun, which are then begun, and the pending changes of the others.
+func (m *SurfaceManager) Flush() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var messages []Message
+	for _, id := range m.order {
+		s := m.surfaces[id]
+		if m.begun[id] {
+			messages = append(messages, s.Flush()...)
+			continue
+		}
+		m.begun[id] = true
+		messages = append(messages, s.Messages()...)
+	}
+	return messages
+}
+
+// Snapshot returns the full state of every begun surface, in the order
+// they were added, for a client that lost track of it (see
+// ReplayBuffer.Resume). Pending changes are included and marked as sent.
+func (m *SurfaceManager) Snapshot() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var messages []Message
+	for _, id := range m.order {
+		if m.begun[id] {
+			messages = append(messages, m.surfaces[id].Messages()...)
+		}
+	}
+	return messages
+}
+
+// Delete stops managing the surface and returns a DeleteSurface message
+// for it, or no messages if it was never begun.
+func (m *SurfaceManager) Delete(id string) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.surfaces[id]; !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownSurface, id)
+	}
+	return m.delete(id), nil
+}
+
+func (m *SurfaceManager) delete(id string) []Message {
+	begun := m.begun[id]
+	delete(m.surfaces, id)
+	delete(m.begun, id)
+	for i, v := range m.order {
+		if v == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	if !begun {
+		return nil
+	}
+	return []Message{{DeleteSurface: &DeleteSurface{SurfaceID: id}}}
+}
+
+// Close deletes all surfaces and returns a DeleteSurface message for each
+// begun one.
+func (m *SurfaceManager) Close() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var messages []Message
+	for len(m.order) > 0 {
+		messages = append(messages, m.delete(m.order[0])...)
+	}
+	return messages
+}
@@ -0,0 +1,106 @@
+package a2ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func messageKinds(msgs []Message) string {
+	var kinds []string
+	for _, msg := range msgs {
+		kinds = append(kinds, msg.SurfaceID()+":"+msg.Kind().String())
+	}
+	return strings.Join(kinds, " ")
+}
+
+func TestSurfaceManagerLifecycle(t *testing.T) {
+	m := NewSurfaceManager()
+	main, err := m.Create("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	main.Add(TextStatic("root", "Hello"))
+	if _, err := m.Create("main"); !errors.Is(err, ErrSurfaceExists) {
+		t.Errorf("expected ErrSurfaceExists, got %v", err)
+	}
+
+	// Updates are refused until the surface is begun
+	if _, err := m.Update("main"); !errors.Is(err, ErrSurfaceNotBegun) {
+		t.Errorf("expected ErrSurfaceNotBegun, got %v", err)
+	}
+	msgs, err := m.Begin("main")
+	if err != nil || messageKinds(msgs) != "main:beginRendering main:updateComponents" {
+		t.Fatalf("unexpected begin: %s, %v", messageKinds(msgs), err)
+	}
+	if !m.Begun("main") {
+		t.Error("expected main to be begun")
+	}
+	main.SetData("/x", 1)
+	if msgs, _ := m.Update("main"); messageKinds(msgs) != "main:dataModelUpdate" {
+		t.Errorf("unexpected update: %s", messageKinds(msgs))
+	}
+
+	// A new surface is deleted silently, a begun one with DeleteSurface
+	if _, err := m.Create("draft"); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, err := m.Delete("draft"); err != nil || len(msgs) != 0 {
+		t.Errorf("expected no messages for an unbegun surface, got %s, %v", messageKinds(msgs), err)
+	}
+	if msgs, _ := m.Delete("main"); messageKinds(msgs) != "main:deleteSurface" {
+		t.Errorf("unexpected delete: %s", messageKinds(msgs))
+	}
+	if _, err := m.Delete("main"); !errors.Is(err, ErrUnknownSurface) || err.Error() != "a2ui: unknown surface: 'main'" {
+		t.Errorf("expected ErrUnknownSurface, got %v", err)
+	}
+	if len(m.IDs()) != 0 || m.Begun("main") {
+		t.Error("expected no surfaces left")
+	}
+}
+
+func TestSurfaceManagerFlushAndClose(t *testing.T) {
+	m := NewSurfaceManager().SetProtocol(ProtocolV08)
+	a, _ := m.Create("a")
+	a.Add(TextStatic("root", "A"))
+	m.Add(NewSurface("b").Add(TextStatic("root", "B")))
+	if err := m.Add(NewSurface("a")); !errors.Is(err, ErrSurfaceExists) {
+		t.Errorf("expected ErrSurfaceExists, got %v", err)
+	}
+
+	if got := messageKinds(m.Flush()); got != "a:updateComponents a:beginRendering b:beginRendering b:updateComponents" {
+		t.Errorf("unexpected first flush: %s", got)
+	}
+	if got := messageKinds(m.Flush()); got != "" {
+		t.Errorf("expected nothing pending, got %s", got)
+	}
+	a.Add(TextStatic("root", "A2"))
+	m.Create("c")
+	if got := messageKinds(m.Flush()); got != "a:updateComponents c:updateComponents c:beginRendering" {
+		t.Errorf("unexpected second flush: %s", got)
+	}
+
+	m.Create("d")
+	if got := messageKinds(m.Close()); got != "a:deleteSurface b:deleteSurface c:deleteSurface" {
+		t.Errorf("unexpected close: %s", got)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Error("expected closed manager to be empty")
+	}
+}
+
+func TestSurfaceManagerSnapshot(t *testing.T) {
+	m := NewSurfaceManager()
+	a, _ := m.Create("a")
+	a.Add(TextStatic("root", "A"))
+	m.Flush()
+	m.Create("b")
+	a.SetData("/x", 1)
+
+	if got := messageKinds(m.Snapshot()); got != "a:beginRendering a:updateComponents a:dataModelUpdate" {
+		t.Errorf("unexpected snapshot: %s", got)
+	}
+	if got := messageKinds(m.Flush()); got != "b:beginRendering b:updateComponents" {
+		t.Errorf("expected snapshot to mark changes sent, got %s", got)
+	}
+}
@@ -0,0 +1,133 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MessageKind identifies which payload of a Message is set.
+type MessageKind int
+
+const (
+	MessageKindInvalid MessageKind = iota
+	MessageKindBeginRendering
+	MessageKindUpdateComponents
+	MessageKindDataModelUpdate
+	MessageKindDeleteSurface
+	MessageKindDataModelPatch
+)
+
+// String returns the JSON field name of the kind.
+func (k MessageKind) String() string {
+	switch k {
+	case MessageKindBeginRendering:
+		return "beginRendering"
+	case MessageKindUpdateComponents:
+		return "updateComponents"
+	case MessageKindDataModelUpdate:
+		return "dataModelUpdate"
+	case MessageKindDeleteSurface:
+		return "deleteSurface"
+	case MessageKindDataModelPatch:
+		return "dataModelPatch"
+	default:
+		return "invalid"
+	}
+}
+
+// MessageError reports a Message that does not have exactly one payload set.
+type MessageError struct {
+	// Kinds lists the payloads that are set (empty if none).
+	Kinds []MessageKind
+}
+
+func (e *MessageError) Error() string {
+	if len(e.Kinds) == 0 {
+		return "a2ui: message has no payload"
+	}
+	names := make([]string, len(e.Kinds))
+	for i, k := range e.Kinds {
+		names[i] = k.String()
+	}
+	return "a2ui: message has multiple payloads: " + strings.Join(names, ", ")
+}
+
+// kinds returns the kinds of all payloads set on m.
+func (m Message) kinds() []MessageKind {
+	var kinds []MessageKind
+	if m.BeginRendering != nil {
+		kinds = append(kinds, MessageKindBeginRendering)
+	}
+	if m.UpdateComponents != nil {
+		kinds = append(kinds, MessageKindUpdateComponents)
+	}
+	if m.DataModelUpdate != nil {
+		kinds = append(kinds, MessageKindDataModelUpdate)
+	}
+	if m.DeleteSurface != nil {
+		kinds = append(kinds, MessageKindDeleteSurface)
+	}
+	if m.DataModelPatch != nil {
+		kinds = append(kinds, MessageKindDataModelPatch)
+	}
+	return kinds
+}
+
+// Kind returns the kind of payload set on the message.
+// It returns MessageKindInvalid if none or more than one payload is set.
+func (m Message) Kind() MessageKind {
+	kinds := m.kinds()
+	if len(kinds) != 1 {
+		return MessageKindInvalid
+	}
+	return kinds[0]
+}
+
+// Validate returns a *MessageError unless exactly one payload is set.
+func (m Message) Validate() error {
+	if kinds := m.kinds(); len(kinds) != 1 {
+		return &MessageError{Kinds: kinds}
+	}
+	return nil
+}
+
+// SurfaceID returns the surface ID of the payload, or "" if the message is invalid.
+func (m Message) SurfaceID() string {
+	switch m.Kind() {
+	case MessageKindBeginRendering:
+		return m.BeginRendering.SurfaceID
+	case MessageKindUpdateComponents:
+		return m.UpdateComponents.SurfaceID
+	case MessageKindDataModelUpdate:
+		return m.DataModelUpdate.SurfaceID
+	case MessageKindDeleteSurface:
+		return m.DeleteSurface.SurfaceID
+	case MessageKindDataModelPatch:
+		return m.DataModelPatch.SurfaceID
+	default:
+		return ""
+	}
+}
+
+// messageFields has the fields of Message without its JSON methods.
+type messageFields Message
+
+// MarshalJSON implements json.Marshaler.
+// It returns a *MessageError unless exactly one payload is set.
+func (m Message) MarshalJSON() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageFields(m))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Components are decoded using
+// DefaultRegistry. It returns a *MessageError unless exactly one payload is set.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	msg, err := decodeMessage(data, DefaultRegistry)
+	if err != nil {
+		return err
+	}
+	*m = msg
+	return nil
+}
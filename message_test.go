@@ -0,0 +1,123 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageKind(t *testing.T) {
+	tests := []struct {
+		msg  Message
+		kind MessageKind
+		name string
+	}{
+		{Message{BeginRendering: &BeginRendering{}}, MessageKindBeginRendering, "beginRendering"},
+		{Message{UpdateComponents: &UpdateComponents{}}, MessageKindUpdateComponents, "updateComponents"},
+		{Message{DataModelUpdate: &DataModelUpdate{}}, MessageKindDataModelUpdate, "dataModelUpdate"},
+		{Message{DeleteSurface: &DeleteSurface{}}, MessageKindDeleteSurface, "deleteSurface"},
+		{Message{}, MessageKindInvalid, "invalid"},
+		{Message{BeginRendering: &BeginRendering{}, DeleteSurface: &DeleteSurface{}}, MessageKindInvalid, "invalid"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.msg.Kind(); got != tt.kind {
+			t.Errorf("expected kind %v, got %v", tt.kind, got)
+		}
+		if got := tt.msg.Kind().String(); got != tt.name {
+			t.Errorf("expected name '%s', got '%s'", tt.name, got)
+		}
+	}
+}
+
+func TestMessageSurfaceID(t *testing.T) {
+	msgs := []Message{
+		{BeginRendering: &BeginRendering{SurfaceID: "s"}},
+		{UpdateComponents: &UpdateComponents{SurfaceID: "s"}},
+		{DataModelUpdate: &DataModelUpdate{SurfaceID: "s"}},
+		{DeleteSurface: &DeleteSurface{SurfaceID: "s"}},
+	}
+	for _, m := range msgs {
+		if m.SurfaceID() != "s" {
+			t.Errorf("expected surface ID 's' for %v", m.Kind())
+		}
+	}
+	if (Message{}).SurfaceID() != "" {
+		t.Error("expected empty surface ID for invalid message")
+	}
+}
+
+func TestMessageMarshalRejectsInvalid(t *testing.T) {
+	_, err := json.Marshal(Message{})
+	var merr *MessageError
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected *MessageError for zero message, got %v", err)
+	}
+	if len(merr.Kinds) != 0 || merr.Error() != "a2ui: message has no payload" {
+		t.Errorf("unexpected error: %v", merr)
+	}
+
+	_, err = json.Marshal(Message{
+		BeginRendering:   &BeginRendering{SurfaceID: "s"},
+		UpdateComponents: &UpdateComponents{SurfaceID: "s"},
+	})
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected *MessageError for two payloads, got %v", err)
+	}
+	if len(merr.Kinds) != 2 || !strings.Contains(merr.Error(), "beginRendering, updateComponents") {
+		t.Errorf("unexpected error: %v", merr)
+	}
+
+	var buf strings.Builder
+	if err := WriteMessage(&buf, Message{}); err == nil {
+		t.Error("expected WriteMessage to fail for zero message")
+	}
+}
+
+func TestMessageMarshalValid(t *testing.T) {
+	data, err := json.Marshal(Message{DeleteSurface: &DeleteSurface{SurfaceID: "s"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"deleteSurface":{"surfaceId":"s"}}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestMessageUnmarshal(t *testing.T) {
+	var m Message
+	data := `{"updateComponents":{"surfaceId":"s","components":[{"id":"t","component":"Text","text":"Hi"}]}}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Kind() != MessageKindUpdateComponents {
+		t.Fatalf("expected updateComponents, got %v", m.Kind())
+	}
+	c, ok := m.UpdateComponents.Components[0].(Component)
+	if !ok || c.Text != "Hi" {
+		t.Errorf("expected decoded Component, got %#v", m.UpdateComponents.Components[0])
+	}
+}
+
+func TestMessageUnmarshalRejectsInvalid(t *testing.T) {
+	inputs := []string{
+		`{}`,
+		`{"unknown":{}}`,
+		`{"beginRendering":{"surfaceId":"s","root":"r"},"deleteSurface":{"surfaceId":"s"}}`,
+	}
+	for _, in := range inputs {
+		var m Message
+		err := json.Unmarshal([]byte(in), &m)
+		var merr *MessageError
+		if !errors.As(err, &merr) {
+			t.Errorf("expected *MessageError for %s, got %v", in, err)
+		}
+	}
+
+	_, err := ReadJSONL(strings.NewReader(`{}`))
+	var derr *DecodeError
+	if !errors.As(err, &derr) || derr.Line != 1 {
+		t.Errorf("expected decoder to reject empty message on line 1, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Patch operation names (RFC 6902). Appends are adds to an array path
+// ending in "-".
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// PatchOp is a single JSON Patch operation on a data model. Paths are JSON
+// Pointers (RFC 6901): "" is the root and "/" the key "".
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// SetRecording enables or disables recording changes as patch operations.
+// Recorded operations are returned by Patch until ResetChanges.
+func (m *DataModel) SetRecording(on bool) *DataModel {
+	m.recording = on
+	if !on {
+		m.patch = nil
+	}
+	return m
+}
+
+// Patch returns the operations recorded since the last ResetChanges.
+func (m *DataModel) Patch() []PatchOp {
+	return append([]PatchOp(nil), m.patch...)
+}
+
+// record appends op to the patch if recording. A replace of the path
+// changed by the previous add or replace updates that operation instead.
+func (m *DataModel) record(op PatchOp) {
+	if !m.recording {
+		return
+	}
+	if n := len(m.patch); n > 0 && op.Op == PatchReplace {
+		last := &m.patch[n-1]
+		if last.Path == op.Path && (last.Op == PatchAdd || last.Op == PatchReplace) {
+			last.Value = op.Value
+			return
+		}
+	}
+	m.patch = append(m.patch, op)
+}
+
+// ApplyPatch applies patch operations in order. Adds create missing
+// intermediate objects and insert into arrays; remove, replace, move and
+// copy require their source to exist; test compares JSON values. The
+// patch is atomic as RFC 6902 requires: if an operation fails, the model
+// is left as it was before the call.
+func (m *DataModel) ApplyPatch(ops []PatchOp) error {
+	saved := m.save()
+	for i, op := range ops {
+		if err := m.applyOp(op); err != nil {
+			m.restore(saved)
+			return fmt.Errorf("a2ui: patch op %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+		if op.Op != PatchTest {
+			m.record(op)
+		}
+	}
+	return nil
+}
+
+// savedModel is the state of a DataModel for restoring after a failed patch.
+type savedModel struct {
+	root             map[string]any
+	changed, removed map[string]bool
+	patch            []PatchOp
+}
+
+// save captures the model's state. Containers are copied on write, so the
+// root is shared; the change sets and the patch are copied.
+func (m *DataModel) save() savedModel {
+	s := savedModel{
+		root:    m.root,
+		changed: make(map[string]bool, len(m.changed)),
+		removed: make(map[string]bool, len(m.removed)),
+		patch:   append([]PatchOp(nil), m.patch...),
+	}
+	for path := range m.changed {
+		s.changed[path] = true
+	}
+	for path := range m.removed {
+		s.removed[path] = true
+	}
+	return s
+}
+
+func (m *DataModel) restore(s savedModel) {
+	m.root, m.changed, m.removed, m.patch = s.root, s.changed, s.removed, s.patch
+}
+
+func (m *DataModel) applyOp(op PatchOp) error {
+	tokens := splitPointer(op.Path)
+	switch op.Op {
+	case PatchAdd:
+		return m.set(tokens, op.Value, true)
+
+	case PatchRemove:
+		if !m.remove(tokens) {
+			return fmt.Errorf("no value at %q", op.Path)
+		}
+		return nil
+
+	case PatchReplace:
+		if _, ok := m.Get(op.Path); !ok {
+			return fmt.Errorf("no value at %q", op.Path)
+		}
+		return m.set(tokens, op.Value, false)
+
+	case PatchMove:
+		return m.move(splitPointer(op.From), tokens)
+
+	case PatchCopy:
+		value, ok := m.Get(op.From)
+		if !ok {
+			return fmt.Errorf("no value at %q", op.From)
+		}
+		return m.set(tokens, deepCopy(value), true)
+
+	case PatchTest:
+		value, ok := m.Get(op.Path)
+		if !ok {
+			return fmt.Errorf("no value at %q", op.Path)
+		}
+		if !jsonEqual(value, op.Value) {
+			return errors.New("value differs")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// jsonEqual reports whether a and b encode to the same JSON value.
+func jsonEqual(a, b any) bool {
+	ga, errA := toGeneric(a)
+	gb, errB := toGeneric(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(ga, gb)
+}
+
+// MarshalJSON keeps "value" for add, replace and test operations even
+// when it is null.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	type plain PatchOp
+	if op.Value != nil {
+		return json.Marshal(plain(op))
+	}
+	switch op.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		return json.Marshal(struct {
+			plain
+			Value any `json:"value"`
+		}{plain: plain(op)})
+	}
+	return json.Marshal(plain(op))
+}
@@ -0,0 +1,176 @@
+package a2ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataModelRecordsPatch(t *testing.T) {
+	m := NewDataModel().SetRecording(true)
+	m.Set("/book/bids", []any{})
+	m.Append("/book/bids", 101.5)
+	m.Set("/book/last", 100)
+	m.Set("/book/last", 101) // coalesced into the previous op
+	m.Set("/book/last", 101) // unchanged, not recorded
+	m.Move("/book/last", "/book/close")
+	m.Delete("/book/bids/0")
+
+	want := []PatchOp{
+		{Op: PatchAdd, Path: "/book", Value: map[string]any{"bids": []any{}}},
+		{Op: PatchAdd, Path: "/book/bids/-", Value: 101.5},
+		{Op: PatchAdd, Path: "/book/last", Value: 101},
+		{Op: PatchMove, From: "/book/last", Path: "/book/close"},
+		{Op: PatchRemove, Path: "/book/bids/0"},
+	}
+	if got := m.Patch(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	m.ResetChanges()
+	if len(m.Patch()) != 0 {
+		t.Error("expected ResetChanges to clear the patch")
+	}
+}
+
+func TestDataModelPatchReplay(t *testing.T) {
+	src := NewDataModel()
+	src.Set("/orders", []any{map[string]any{"id": "a", "qty": 1}})
+	replica := src.Clone()
+	src.SetRecording(true)
+
+	src.Set("/orders/0/qty", 5)
+	src.Append("/orders", map[string]any{"id": "b", "qty": 2})
+	src.Set("/status", "open")
+	src.Move("/orders/1", "/orders/0")
+	src.Delete("/status")
+
+	if err := replica.ApplyPatch(src.Patch()); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(src)
+	got, _ := json.Marshal(replica)
+	if !bytes.Equal(want, got) {
+		t.Errorf("replica differs:\nwant %s\ngot  %s", want, got)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	m := NewDataModel()
+	m.Set("/list", []any{"a", "c"})
+
+	err := m.ApplyPatch([]PatchOp{
+		{Op: PatchAdd, Path: "/list/1", Value: "b"}, // inserts
+		{Op: PatchReplace, Path: "/list/0", Value: "A"},
+		{Op: PatchCopy, From: "/list", Path: "/copy"},
+		{Op: PatchTest, Path: "/list", Value: []string{"A", "b", "c"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := m.Get("/list"); !reflect.DeepEqual(list, []any{"A", "b", "c"}) {
+		t.Errorf("unexpected list: %v", list)
+	}
+	if c, _ := m.Get("/copy/2"); c != "c" {
+		t.Errorf("expected copied list, got %v", c)
+	}
+
+	tests := []struct {
+		name string
+		op   PatchOp
+	}{
+		{"ReplaceMissing", PatchOp{Op: PatchReplace, Path: "/nope", Value: 1}},
+		{"RemoveMissing", PatchOp{Op: PatchRemove, Path: "/nope"}},
+		{"MoveIntoItself", PatchOp{Op: PatchMove, From: "/list", Path: "/list/0"}},
+		{"TestFails", PatchOp{Op: PatchTest, Path: "/list/0", Value: "z"}},
+		{"UnknownOp", PatchOp{Op: "frobnicate", Path: "/list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.ApplyPatch([]PatchOp{tt.op}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestApplyPatchIsAtomic(t *testing.T) {
+	m := NewDataModel().SetRecording(true)
+	m.Set("/a", 1)
+	m.ResetChanges()
+
+	err := m.ApplyPatch([]PatchOp{
+		{Op: PatchReplace, Path: "/a", Value: 2},
+		{Op: PatchAdd, Path: "/b", Value: 3},
+		{Op: PatchRemove, Path: "/missing"},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if a, _ := m.Get("/a"); a != 1 {
+		t.Errorf("expected /a unchanged, got %v", a)
+	}
+	if _, ok := m.Get("/b"); ok {
+		t.Error("expected /b not added")
+	}
+	if m.HasChanges() || len(m.Patch()) != 0 {
+		t.Errorf("expected no recorded changes, got %v %v", m.Changes(), m.Patch())
+	}
+}
+
+func TestPatchOpJSON(t *testing.T) {
+	data, _ := json.Marshal([]PatchOp{
+		{Op: PatchAdd, Path: "/x", Value: nil},
+		{Op: PatchRemove, Path: "/y"},
+	})
+	want := `[{"op":"add","path":"/x","value":null},{"op":"remove","path":"/y"}]`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
+
+func TestSurfaceDataPatches(t *testing.T) {
+	s := NewSurface("book").SetDataPatches(true)
+	s.Add(TextStatic("root", "Book"))
+	s.SetData("/bids", []any{100})
+	s.Messages()
+
+	s.Batch(func(b *Batch) { b.Data().Append("/bids", 101) })
+	msgs := s.Flush()
+	if len(msgs) != 1 || msgs[0].Kind() != MessageKindDataModelPatch {
+		t.Fatalf("expected a single DataModelPatch, got %+v", msgs)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, msgs); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"dataModelPatch":{"surfaceId":"book","patch":[{"op":"add","path":"/bids/-","value":101}]}}`) {
+		t.Errorf("unexpected JSON: %s", buf.String())
+	}
+
+	decoded, err := ReadJSONL(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewDataModel()
+	client.Set("/bids", []any{100})
+	if err := client.ApplyPatch(decoded[0].DataModelPatch.Patch); err != nil {
+		t.Fatal(err)
+	}
+	if bids, _ := client.Get("/bids"); !reflect.DeepEqual(bids, []any{100, float64(101)}) {
+		t.Errorf("unexpected client bids: %v", bids)
+	}
+
+	// Versioned protocols have no patch message
+	if _, err := EncodeMessage(msgs[0], ProtocolV09); err == nil {
+		t.Error("expected v0.9 encoding of a patch to fail")
+	}
+	s.SetProtocol(ProtocolV09)
+	s.SetData("/ask", 102)
+	if msgs := s.Flush(); msgs[0].Kind() != MessageKindDataModelUpdate {
+		t.Errorf("expected DataModelUpdate for v0.9, got %v", msgs[0].Kind())
+	}
+}
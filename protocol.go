@@ -0,0 +1,793 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProtocolVersion selects the wire format used to encode messages.
+type ProtocolVersion string
+
+const (
+	// ProtocolDefault is this library's native format: beginRendering,
+	// updateComponents with flat components, and dataModelUpdate with
+	// contents keyed by JSON Pointer path.
+	ProtocolDefault ProtocolVersion = ""
+
+	// ProtocolV08 is A2UI v0.8: surfaceUpdate with nested component shapes
+	// and bound values, dataModelUpdate with key/value content lists, and
+	// beginRendering sent after the initial components and data.
+	ProtocolV08 ProtocolVersion = "v0.8"
+
+	// ProtocolV09 is A2UI v0.9: createSurface, updateComponents with flat
+	// components, and updateDataModel with one path and value per message.
+	// The root component must have the ID "root".
+	ProtocolV09 ProtocolVersion = "v0.9"
+)
+
+// Encoder writes messages as JSON Lines in a given protocol version.
+type Encoder struct {
+	w       io.Writer
+	version ProtocolVersion
+}
+
+// NewEncoder creates an encoder writing ProtocolDefault to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetProtocol sets the protocol version used for subsequent messages.
+func (e *Encoder) SetProtocol(v ProtocolVersion) *Encoder {
+	e.version = v
+	return e
+}
+
+// Encode writes a single message. Depending on the protocol version one
+// message may be written as several lines (for example one updateDataModel
+// per path in v0.9).
+func (e *Encoder) Encode(msg Message) error {
+	lines, err := EncodeMessage(msg, e.version)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := e.w.Write(line); err != nil {
+			return err
+		}
+		if _, err := e.w.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeAll writes all messages in order.
+func (e *Encoder) EncodeAll(messages []Message) error {
+	for _, msg := range messages {
+		if err := e.Encode(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeMessage encodes msg in the given protocol version and returns one
+// JSON document per output line, without trailing newlines.
+func EncodeMessage(msg Message, v ProtocolVersion) ([][]byte, error) {
+	if err := msg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch v {
+	case ProtocolDefault:
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{data}, nil
+	case ProtocolV08, ProtocolV09:
+		if msg.DataModelPatch != nil {
+			return nil, fmt.Errorf("a2ui: %s does not support dataModelPatch", string(v))
+		}
+		if v == ProtocolV08 {
+			return encodeV08(msg)
+		}
+		return encodeV09(msg)
+	default:
+		return nil, fmt.Errorf("a2ui: unknown protocol version %q", string(v))
+	}
+}
+
+// marshalLines marshals each value into its own line.
+func marshalLines(values ...any) ([][]byte, error) {
+	lines := make([][]byte, 0, len(values))
+	for _, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, data)
+	}
+	return lines, nil
+}
+
+// sortedPaths returns the keys of contents in sorted order.
+func sortedPaths(contents map[string]any) []string {
+	paths := make([]string, 0, len(contents))
+	for p := range contents {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func encodeV09(msg Message) ([][]byte, error) {
+	switch msg.Kind() {
+	case MessageKindBeginRendering:
+		if msg.BeginRendering.Root != "root" {
+			return nil, fmt.Errorf("a2ui: v0.9 requires root component ID \"root\", got %q", msg.BeginRendering.Root)
+		}
+		return marshalLines(map[string]any{
+			"createSurface": map[string]any{"surfaceId": msg.BeginRendering.SurfaceID},
+		})
+
+	case MessageKindUpdateComponents:
+		u := msg.UpdateComponents
+		components := make([]any, 0, len(u.Components))
+		for _, c := range u.Components {
+			comp, err := componentToV09(c)
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, comp)
+		}
+		return marshalLines(map[string]any{
+			"updateComponents": map[string]any{"surfaceId": u.SurfaceID, "components": components},
+		})
+
+	case MessageKindDataModelUpdate:
+		u := msg.DataModelUpdate
+		var values []any
+		for _, path := range sortedPaths(u.Contents) {
+			update := map[string]any{"surfaceId": u.SurfaceID, "path": path}
+			if v := u.Contents[path]; v != nil {
+				update["value"] = v
+			} // an omitted value removes the path
+			values = append(values, map[string]any{"updateDataModel": update})
+		}
+		return marshalLines(values...)
+
+	default:
+		return marshalLines(msg)
+	}
+}
+
+func encodeV08(msg Message) ([][]byte, error) {
+	switch msg.Kind() {
+	case MessageKindUpdateComponents:
+		u := msg.UpdateComponents
+		components := make([]any, 0, len(u.Components))
+		for _, c := range u.Components {
+			nested, err := componentToV08(c)
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, nested)
+		}
+		return marshalLines(map[string]any{
+			"surfaceUpdate": map[string]any{"surfaceId": u.SurfaceID, "components": components},
+		})
+
+	case MessageKindDataModelUpdate:
+		u := msg.DataModelUpdate
+		var values []any
+		for _, path := range sortedPaths(u.Contents) {
+			tokens := splitPointer(path)
+			if len(tokens) == 0 {
+				entries, err := v08Entries(u.Contents[path])
+				if err != nil {
+					return nil, fmt.Errorf("a2ui: v0.8 data at %q: %w", path, err)
+				}
+				values = append(values, v08DataUpdate(u.SurfaceID, "/", entries))
+				continue
+			}
+			entry, err := v08Entry(tokens[len(tokens)-1], u.Contents[path])
+			if err != nil {
+				return nil, fmt.Errorf("a2ui: v0.8 data at %q: %w", path, err)
+			}
+			values = append(values, v08DataUpdate(u.SurfaceID, v08Path(tokens[:len(tokens)-1]), []any{entry}))
+		}
+		return marshalLines(values...)
+
+	default:
+		return marshalLines(msg)
+	}
+}
+
+// v08Path returns the pointer for tokens as v0.8 writes it, with "/" for
+// the root.
+func v08Path(tokens []string) string {
+	if len(tokens) == 0 {
+		return "/"
+	}
+	return joinPointer(tokens)
+}
+
+func v08DataUpdate(surfaceID, path string, entries []any) map[string]any {
+	return map[string]any{
+		"dataModelUpdate": map[string]any{
+			"surfaceId": surfaceID,
+			"path":      path,
+			"contents":  entries,
+		},
+	}
+}
+
+// v08Entries converts an object or array into v0.8 key/value entries.
+func v08Entries(value any) ([]any, error) {
+	generic, err := toGeneric(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []any
+	switch v := generic.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e, err := v08Entry(k, v[k])
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	case []any:
+		for i, item := range v {
+			e, err := v08Entry(strconv.Itoa(i), item)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	default:
+		return nil, fmt.Errorf("expected object or array, got %T", generic)
+	}
+	return entries, nil
+}
+
+// v08Entry converts a single key and value into a v0.8 content entry.
+// Arrays are encoded as maps keyed by index. v0.8 has no null or removal,
+// so nil, which DataModelUpdate contents use for removed paths, becomes an
+// entry with only a key; the decoder reads it back as nil.
+func v08Entry(key string, value any) (map[string]any, error) {
+	generic, err := toGeneric(value)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := map[string]any{"key": key}
+	switch v := generic.(type) {
+	case string:
+		entry["valueString"] = v
+	case float64:
+		entry["valueNumber"] = v
+	case bool:
+		entry["valueBoolean"] = v
+	case nil:
+		// key only: no value
+	default:
+		entries, err := v08Entries(v)
+		if err != nil {
+			return nil, err
+		}
+		entry["valueMap"] = entries
+	}
+	return entry, nil
+}
+
+// fromV08Entries converts v0.8 key/value entries back into a value.
+// Maps whose keys are exactly "0".."n-1" become arrays.
+func fromV08Entries(entries []map[string]json.RawMessage) (any, error) {
+	obj := make(map[string]any, len(entries))
+	for _, e := range entries {
+		var key string
+		if err := json.Unmarshal(e["key"], &key); err != nil {
+			return nil, fmt.Errorf("entry key: %w", err)
+		}
+		value, err := fromV08Entry(e)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", key, err)
+		}
+		obj[key] = value
+	}
+
+	arr := make([]any, len(obj))
+	for i := range arr {
+		v, ok := obj[strconv.Itoa(i)]
+		if !ok {
+			return obj, nil
+		}
+		arr[i] = v
+	}
+	if len(arr) == 0 {
+		return obj, nil
+	}
+	return arr, nil
+}
+
+func fromV08Entry(e map[string]json.RawMessage) (any, error) {
+	for _, field := range []string{"valueString", "valueNumber", "valueBoolean"} {
+		if raw, ok := e[field]; ok {
+			var v any
+			err := json.Unmarshal(raw, &v)
+			return v, err
+		}
+	}
+	if raw, ok := e["valueMap"]; ok {
+		var entries []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, err
+		}
+		return fromV08Entries(entries)
+	}
+	return nil, nil
+}
+
+// toGeneric converts v into its generic JSON representation
+// (map[string]any, []any, string, float64, bool or nil).
+func toGeneric(v any) (any, error) {
+	switch v.(type) {
+	case nil, string, float64, bool, map[string]any, []any:
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
+
+// v08Conv identifies how a flat component field maps to a v0.8 property.
+type v08Conv int
+
+const (
+	v08Plain        v08Conv = iota // copied unchanged
+	v08Literal                     // wrapped as {"literalString": ...}
+	v08BoundString                 // {"literalString": ...} or {"path": ...}
+	v08BoundNumber                 // {"literalNumber": ...} or {"path": ...}
+	v08BoundBool                   // {"literalBoolean": ...} or {"path": ...}
+	v08BoundArray                  // {"literalArray": ...} or {"path": ...}
+	v08ExplicitList                // {"explicitList": [...]}
+	v08Template                    // {"template": {"componentId", "dataBinding"}} from template and dataBinding
+	v08TabItems                    // [{"title": {"literalString"}, "child"}]
+	v08Options                     // [{"label": {"literalString"}, "value"}]
+	v08Action                      // {"name", "context": [{"key", "value"}]}
+)
+
+// v08Field maps a flat field to a v0.8 property. An empty flat name means
+// the property is only ever bound through dataBinding.
+type v08Field struct {
+	flat   string
+	nested string
+	conv   v08Conv
+}
+
+// v08Fields lists the property mappings of each standard component.
+// Flat fields not listed are copied unchanged into the v0.8 properties.
+var v08Fields = map[string][]v08Field{
+	"Text":           {{"text", "text", v08BoundString}},
+	"Image":          {{"url", "url", v08BoundString}},
+	"Icon":           {{"icon", "name", v08Literal}},
+	"Video":          {{"url", "url", v08BoundString}},
+	"AudioPlayer":    {{"url", "url", v08BoundString}, {"description", "description", v08Literal}},
+	"Row":            {{"children", "children", v08ExplicitList}},
+	"Column":         {{"children", "children", v08ExplicitList}},
+	"List":           {{"template", "children", v08Template}},
+	"Tabs":           {{"tabs", "tabItems", v08TabItems}},
+	"Divider":        {{"orientation", "axis", v08Plain}},
+	"Button":         {{"action", "action", v08Action}},
+	"CheckBox":       {{"label", "label", v08Literal}, {"checked", "value", v08BoundBool}},
+	"TextField":      {{"label", "label", v08Literal}, {"text", "text", v08BoundString}},
+	"DateTimeInput":  {{"", "value", v08BoundString}},
+	"MultipleChoice": {{"options", "options", v08Options}, {"selections", "selections", v08BoundArray}},
+	"Slider":         {{"value", "value", v08BoundNumber}},
+}
+
+var v08LiteralKeys = map[v08Conv]string{
+	v08BoundString: "literalString",
+	v08BoundNumber: "literalNumber",
+	v08BoundBool:   "literalBoolean",
+	v08BoundArray:  "literalArray",
+}
+
+// componentToV08 converts a flat component into the v0.8 shape
+// {"id": ..., "component": {"<Type>": {...properties}}}.
+func componentToV08(c any) (map[string]any, error) {
+	generic, err := toGeneric(c)
+	if err != nil {
+		return nil, err
+	}
+	flat, ok := generic.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("a2ui: component must be an object, got %T", generic)
+	}
+
+	id, _ := flat["id"].(string)
+	typ, _ := flat["component"].(string)
+	delete(flat, "id")
+	delete(flat, "component")
+
+	var path any
+	if b, ok := flat["dataBinding"].(map[string]any); ok {
+		path = b["path"]
+	}
+	bindingUsed := false
+	props := make(map[string]any)
+
+	for _, f := range v08Fields[typ] {
+		value, has := flat[f.flat]
+		if f.flat != "" {
+			delete(flat, f.flat)
+		}
+
+		switch f.conv {
+		case v08Plain:
+			if has {
+				props[f.nested] = value
+			}
+		case v08Literal:
+			if has {
+				props[f.nested] = map[string]any{"literalString": value}
+			}
+		case v08BoundString, v08BoundNumber, v08BoundBool, v08BoundArray:
+			if path != nil && !bindingUsed {
+				props[f.nested] = map[string]any{"path": path}
+				bindingUsed = true
+			} else if has {
+				props[f.nested] = map[string]any{v08LiteralKeys[f.conv]: value}
+			}
+		case v08ExplicitList:
+			if has {
+				props[f.nested] = map[string]any{"explicitList": value}
+			}
+		case v08Template:
+			if has {
+				tmpl := map[string]any{"componentId": value}
+				if path != nil {
+					tmpl["dataBinding"] = path
+					bindingUsed = true
+				}
+				props[f.nested] = map[string]any{"template": tmpl}
+			}
+		case v08TabItems:
+			tabs, _ := value.([]any)
+			items := make([]any, 0, len(tabs))
+			for _, t := range tabs {
+				tab, _ := t.(map[string]any)
+				items = append(items, map[string]any{
+					"title": map[string]any{"literalString": tab["title"]},
+					"child": tab["child"],
+				})
+			}
+			if has {
+				props[f.nested] = items
+			}
+		case v08Options:
+			opts, _ := value.([]any)
+			items := make([]any, 0, len(opts))
+			for _, o := range opts {
+				opt, _ := o.(map[string]any)
+				items = append(items, map[string]any{
+					"label": map[string]any{"literalString": opt["label"]},
+					"value": opt["value"],
+				})
+			}
+			if has {
+				props[f.nested] = items
+			}
+		case v08Action:
+			if has {
+				props[f.nested] = actionToV08(value)
+			}
+		}
+	}
+
+	if bindingUsed {
+		delete(flat, "dataBinding")
+	}
+	for k, v := range flat {
+		props[k] = v
+	}
+
+	return map[string]any{
+		"id":        id,
+		"component": map[string]any{typ: props},
+	}, nil
+}
+
+// actionToV08 converts {"type", "data"} into {"name", "context"}.
+func actionToV08(value any) map[string]any {
+	action, _ := value.(map[string]any)
+	result := map[string]any{"name": action["type"]}
+
+	data, _ := action["data"].(map[string]any)
+	if len(data) == 0 {
+		return result
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	context := make([]any, 0, len(keys))
+	for _, k := range keys {
+		var bound map[string]any
+		switch v := data[k].(type) {
+		case string:
+			bound = map[string]any{"literalString": v}
+		case float64:
+			bound = map[string]any{"literalNumber": v}
+		case bool:
+			bound = map[string]any{"literalBoolean": v}
+		default:
+			encoded, _ := json.Marshal(v)
+			bound = map[string]any{"literalString": string(encoded)}
+		}
+		context = append(context, map[string]any{"key": k, "value": bound})
+	}
+	result["context"] = context
+	return result
+}
+
+// componentFromV08 converts a v0.8 component into the flat JSON shape.
+func componentFromV08(id string, nested map[string]json.RawMessage) ([]byte, error) {
+	if len(nested) != 1 {
+		return nil, fmt.Errorf("v0.8 component must have exactly one type, got %d", len(nested))
+	}
+
+	var typ string
+	var props map[string]any
+	for t, raw := range nested {
+		typ = t
+		if err := json.Unmarshal(raw, &props); err != nil {
+			return nil, err
+		}
+	}
+
+	flat := map[string]any{"id": id, "component": typ}
+	for _, f := range v08Fields[typ] {
+		value, has := props[f.nested]
+		if !has {
+			continue
+		}
+		delete(props, f.nested)
+		obj, _ := value.(map[string]any)
+
+		switch f.conv {
+		case v08Plain:
+			flat[f.flat] = value
+		case v08Literal:
+			flat[f.flat] = obj["literalString"]
+		case v08BoundString, v08BoundNumber, v08BoundBool, v08BoundArray:
+			if p, ok := obj["path"]; ok {
+				flat["dataBinding"] = map[string]any{"path": p}
+			} else if f.flat != "" {
+				flat[f.flat] = obj[v08LiteralKeys[f.conv]]
+			}
+		case v08ExplicitList:
+			flat[f.flat] = obj["explicitList"]
+		case v08Template:
+			if tmpl, ok := obj["template"].(map[string]any); ok {
+				flat[f.flat] = tmpl["componentId"]
+				if p, ok := tmpl["dataBinding"]; ok {
+					flat["dataBinding"] = map[string]any{"path": p}
+				}
+			} else if list, ok := obj["explicitList"]; ok {
+				flat["children"] = list
+			}
+		case v08TabItems:
+			items, _ := value.([]any)
+			tabs := make([]any, 0, len(items))
+			for _, it := range items {
+				item, _ := it.(map[string]any)
+				title, _ := item["title"].(map[string]any)
+				tabs = append(tabs, map[string]any{"title": title["literalString"], "child": item["child"]})
+			}
+			flat[f.flat] = tabs
+		case v08Options:
+			items, _ := value.([]any)
+			opts := make([]any, 0, len(items))
+			for _, it := range items {
+				item, _ := it.(map[string]any)
+				label, _ := item["label"].(map[string]any)
+				opts = append(opts, map[string]any{"label": label["literalString"], "value": item["value"]})
+			}
+			flat[f.flat] = opts
+		case v08Action:
+			flat[f.flat] = actionFromV08(obj)
+		}
+	}
+	for k, v := range props {
+		flat[k] = v
+	}
+	return json.Marshal(flat)
+}
+
+// actionFromV08 converts {"name", "context"} into {"type", "data"}.
+func actionFromV08(obj map[string]any) map[string]any {
+	action := map[string]any{"type": obj["name"]}
+	context, _ := obj["context"].([]any)
+	if len(context) == 0 {
+		return action
+	}
+	data := make(map[string]any, len(context))
+	for _, c := range context {
+		entry, _ := c.(map[string]any)
+		key, _ := entry["key"].(string)
+		bound, _ := entry["value"].(map[string]any)
+		for _, lit := range []string{"literalString", "literalNumber", "literalBoolean", "path"} {
+			if v, ok := bound[lit]; ok {
+				data[key] = v
+				break
+			}
+		}
+	}
+	action["data"] = data
+	return action
+}
+
+// v09Bound names the property of each standard component that carries its
+// data binding in v0.9, where a bound value is {"path": ...} in place of
+// the literal.
+var v09Bound = map[string]string{
+	"Text":           "text",
+	"Image":          "url",
+	"Video":          "url",
+	"AudioPlayer":    "url",
+	"CheckBox":       "checked",
+	"TextField":      "text",
+	"DateTimeInput":  "value",
+	"MultipleChoice": "selections",
+	"Slider":         "value",
+}
+
+// componentToV09 converts a flat component into the v0.9 shape: bindings
+// move into the bound property, a bound List's template becomes
+// {"children": {"componentId", "path"}} and actions become {"name", "context"}.
+// Other properties are unchanged.
+func componentToV09(c any) (map[string]any, error) {
+	generic, err := toGeneric(c)
+	if err != nil {
+		return nil, err
+	}
+	flat, ok := generic.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("a2ui: component must be an object, got %T", generic)
+	}
+
+	typ, _ := flat["component"].(string)
+	if b, ok := flat["dataBinding"].(map[string]any); ok {
+		if tmpl, ok := flat["template"]; ok && typ == "List" {
+			flat["children"] = map[string]any{"componentId": tmpl, "path": b["path"]}
+			delete(flat, "template")
+			delete(flat, "dataBinding")
+		} else if prop, ok := v09Bound[typ]; ok {
+			flat[prop] = map[string]any{"path": b["path"]}
+			delete(flat, "dataBinding")
+		}
+	}
+	if action, ok := flat["action"].(map[string]any); ok {
+		v09 := map[string]any{"name": action["type"]}
+		if data, _ := action["data"].(map[string]any); len(data) > 0 {
+			v09["context"] = data
+		}
+		flat["action"] = v09
+	}
+	return flat, nil
+}
+
+// componentFromV09 converts v0.9 bindings, list children and actions of a
+// flat component back into the library's shape. It reports whether
+// anything changed; components already in the library's shape are left
+// alone.
+func componentFromV09(flat map[string]any) bool {
+	changed := false
+	typ, _ := flat["component"].(string)
+	if children, ok := flat["children"].(map[string]any); ok && typ == "List" {
+		flat["template"] = children["componentId"]
+		if p, ok := children["path"]; ok {
+			flat["dataBinding"] = map[string]any{"path": p}
+		}
+		delete(flat, "children")
+		changed = true
+	}
+	if prop, ok := v09Bound[typ]; ok {
+		if bound, ok := flat[prop].(map[string]any); ok && len(bound) == 1 {
+			if p, ok := bound["path"]; ok {
+				flat["dataBinding"] = map[string]any{"path": p}
+				delete(flat, prop)
+				changed = true
+			}
+		}
+	}
+	if action, ok := flat["action"].(map[string]any); ok {
+		_, hasName := action["name"]
+		_, hasType := action["type"]
+		if hasName && !hasType {
+			converted := map[string]any{"type": action["name"]}
+			if ctx, ok := action["context"].(map[string]any); ok && len(ctx) > 0 {
+				converted["data"] = ctx
+			}
+			flat["action"] = converted
+			changed = true
+		}
+	}
+	return changed
+}
+
+// normalizeComponent converts a component in any supported wire shape into
+// the flat shape understood by Registry.Decode.
+func normalizeComponent(raw json.RawMessage) ([]byte, error) {
+	var head struct {
+		ID        string          `json:"id"`
+		Component json.RawMessage `json:"component"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(string(head.Component))
+	if !strings.HasPrefix(trimmed, "{") {
+		var flat map[string]any
+		if err := json.Unmarshal(raw, &flat); err != nil {
+			return nil, err
+		}
+		if !componentFromV09(flat) {
+			return raw, nil
+		}
+		return json.Marshal(flat)
+	}
+	var nested map[string]json.RawMessage
+	if err := json.Unmarshal(head.Component, &nested); err != nil {
+		return nil, err
+	}
+	return componentFromV08(head.ID, nested)
+}
+
+// splitPointer splits a JSON Pointer (RFC 6901) into unescaped reference
+// tokens. The empty pointer is the root and yields no tokens; "/" refers
+// to the key "". A missing leading "/" is tolerated.
+func splitPointer(path string) []string {
+	if path == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens
+}
+
+// joinPointer builds a JSON Pointer from reference tokens. No tokens
+// yield the empty pointer, the root.
+func joinPointer(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
+	}
+	return b.String()
+}
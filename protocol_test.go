@@ -0,0 +1,287 @@
+package a2ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// protocolSurface builds a surface covering every standard component.
+func protocolSurface() *Surface {
+	s := NewSurface("demo")
+	s.Add(ColumnWithLayout("root", DistributionStart, AlignmentCenter, "title", "row", "list", "tabs"))
+	s.Add(TextWithHint("title", "Hello", UsageHintH1))
+	s.Add(Row("row", "img", "icon", "video", "audio", "div"))
+	s.Add(ImageWithFit("img", "https://example.com/a.png", "A", ImageFitCover))
+	s.Add(Icon("icon", IconStar))
+	s.Add(VideoBound("video", "/video"))
+	s.Add(AudioPlayer("audio", "https://example.com/a.mp3", "Song"))
+	s.Add(DividerVertical("div"))
+	s.Add(ListTemplate("list", "item", "/items"))
+	s.Add(Card("item", "item-name"))
+	s.Add(TextBound("item-name", "/name"))
+	s.Add(Tabs("tabs", Tab("Form", "form"), Tab("Modal", "modal")))
+	s.Add(Column("form", "name", "agree", "when", "pick", "level", "submit"))
+	s.Add(TextFieldBound("name", "Name", "Your name", "/form/name"))
+	s.Add(CheckBox("agree", "Agree", true))
+	s.Add(DateTimeInputBound("when", "When", "/form/when", true, false))
+	s.Add(MultipleChoice("pick", "Pick", []ChoiceOption{Choice("A", "a"), Choice("B", "b")}))
+	s.Add(Slider("level", "Level", 0, 10, 5))
+	s.AddAll(ButtonWithData("submit", "Send", "submit", map[string]any{"endpoint": "/submit", "retries": float64(2)})...)
+	s.Add(Modal("modal", "submit", "title"))
+	s.SetData("/items", []any{map[string]any{"name": "x"}})
+	s.SetData("/form/name", "Alice")
+	return s
+}
+
+func encodeAll(t *testing.T, v ProtocolVersion, messages []Message) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).SetProtocol(v).EncodeAll(messages); err != nil {
+		t.Fatalf("EncodeAll(%q) failed: %v", v, err)
+	}
+	return buf.String()
+}
+
+func TestEncoderDefaultMatchesWriteJSONL(t *testing.T) {
+	msgs := protocolSurface().Messages()
+
+	var want bytes.Buffer
+	if err := WriteJSONL(&want, msgs); err != nil {
+		t.Fatal(err)
+	}
+	if got := encodeAll(t, ProtocolDefault, msgs); got != want.String() {
+		t.Errorf("default encoder differs from WriteJSONL:\n%s\nvs\n%s", got, want.String())
+	}
+}
+
+func TestEncoderV08Shapes(t *testing.T) {
+	s := protocolSurface().SetProtocol(ProtocolV08)
+	out := encodeAll(t, ProtocolV08, s.Messages())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	if !strings.HasPrefix(lines[0], `{"surfaceUpdate":`) {
+		t.Errorf("expected surfaceUpdate first, got %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], `{"beginRendering":`) {
+		t.Errorf("expected beginRendering last, got %s", lines[len(lines)-1])
+	}
+
+	checks := []string{
+		`{"Text":{"text":{"literalString":"Hello"},"usageHint":"h1"}}`,
+		`{"Text":{"text":{"path":"/name"}}}`,
+		`"children":{"explicitList":["title","row","list","tabs"]}`,
+		`"children":{"template":{"componentId":"item","dataBinding":"/items"}}`,
+		`{"Icon":{"name":{"literalString":"star"}}}`,
+		`{"Divider":{"axis":"vertical"}}`,
+		`"tabItems":[{"child":"form","title":{"literalString":"Form"}}`,
+		`"value":{"literalBoolean":true}`,
+		`"value":{"path":"/form/when"}`,
+		`"options":[{"label":{"literalString":"A"},"value":"a"}`,
+		`"value":{"literalNumber":5}`,
+		`"action":{"context":[{"key":"endpoint","value":{"literalString":"/submit"}},{"key":"retries","value":{"literalNumber":2}}],"name":"submit"}`,
+		`{"dataModelUpdate":{"contents":[{"key":"name","valueString":"Alice"}],"path":"/form","surfaceId":"demo"}}`,
+		`{"key":"items","valueMap":[{"key":"0","valueMap":[{"key":"name","valueString":"x"}]}]}`,
+	}
+	for _, c := range checks {
+		if !strings.Contains(out, c) {
+			t.Errorf("expected v0.8 output to contain %s", c)
+		}
+	}
+}
+
+func TestEncoderV09Shapes(t *testing.T) {
+	out := encodeAll(t, ProtocolV09, protocolSurface().Messages())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	if lines[0] != `{"createSurface":{"surfaceId":"demo"}}` {
+		t.Errorf("unexpected createSurface: %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `{"updateComponents":`) {
+		t.Errorf("expected updateComponents, got %s", lines[1])
+	}
+	checks := []string{
+		`{"component":"Text","id":"title","text":"Hello","usageHint":"h1"}`,
+		`{"component":"Text","id":"item-name","text":{"path":"/name"}}`,
+		`{"children":{"componentId":"item","path":"/items"},"component":"List","id":"list"}`,
+		`{"component":"Video","id":"video","url":{"path":"/video"}}`,
+		`{"component":"TextField","id":"name","label":"Name","placeholder":"Your name","text":{"path":"/form/name"}}`,
+		`{"component":"DateTimeInput","enableDate":true,"id":"when","label":"When","value":{"path":"/form/when"}}`,
+		`"action":{"context":{"endpoint":"/submit","retries":2},"name":"submit"}`,
+	}
+	for _, c := range checks {
+		if !strings.Contains(lines[1], c) {
+			t.Errorf("expected v0.9 components to contain %s", c)
+		}
+	}
+	if strings.Contains(lines[1], `"dataBinding"`) || strings.Contains(lines[1], `"template"`) {
+		t.Errorf("expected no library binding shapes in v0.9 output: %s", lines[1])
+	}
+	if len(lines) != 4 {
+		t.Fatalf("expected one updateDataModel per path (4 lines), got %d", len(lines))
+	}
+	if lines[2] != `{"updateDataModel":{"path":"/form/name","surfaceId":"demo","value":"Alice"}}` {
+		t.Errorf("unexpected updateDataModel: %s", lines[2])
+	}
+}
+
+func TestEncoderV09RequiresRootID(t *testing.T) {
+	s := NewSurface("s").SetRoot("main")
+	s.Add(TextStatic("main", "x"))
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).SetProtocol(ProtocolV09).EncodeAll(s.Messages()); err == nil {
+		t.Error("expected error for non-root root ID in v0.9")
+	}
+}
+
+func TestEncoderUnknownVersion(t *testing.T) {
+	_, err := EncodeMessage(Message{DeleteSurface: &DeleteSurface{SurfaceID: "s"}}, "v9.9")
+	if err == nil {
+		t.Error("expected error for unknown protocol version")
+	}
+}
+
+func TestDecoderReadsAllVersions(t *testing.T) {
+	s := protocolSurface()
+	want := s.Messages()
+	wantComponents := want[1].UpdateComponents.Components
+
+	for _, v := range []ProtocolVersion{ProtocolDefault, ProtocolV08, ProtocolV09} {
+		t.Run(string(v), func(t *testing.T) {
+			out := encodeAll(t, v, want)
+			msgs, err := ReadJSONL(strings.NewReader(out))
+			if err != nil {
+				t.Fatalf("ReadJSONL failed: %v", err)
+			}
+
+			var components []any
+			data := make(map[string]any)
+			var begin *BeginRendering
+			for _, m := range msgs {
+				switch m.Kind() {
+				case MessageKindBeginRendering:
+					begin = m.BeginRendering
+				case MessageKindUpdateComponents:
+					components = m.UpdateComponents.Components
+				case MessageKindDataModelUpdate:
+					for p, val := range m.DataModelUpdate.Contents {
+						data[p] = val
+					}
+				}
+			}
+
+			if begin == nil || begin.SurfaceID != "demo" || begin.Root != "root" {
+				t.Errorf("unexpected begin: %+v", begin)
+			}
+			if len(components) != len(wantComponents) {
+				t.Fatalf("expected %d components, got %d", len(wantComponents), len(components))
+			}
+			if !reflect.DeepEqual(components, wantComponents) {
+				for i := range wantComponents {
+					if i < len(components) && !reflect.DeepEqual(components[i], wantComponents[i]) {
+						t.Errorf("component %d differs:\n got %+v\nwant %+v", i, components[i], wantComponents[i])
+					}
+				}
+			}
+
+			gotData, _ := json.Marshal(data)
+			wantData, _ := json.Marshal(map[string]any{
+				"/items":     []any{map[string]any{"name": "x"}},
+				"/form/name": "Alice",
+			})
+			if string(gotData) != string(wantData) {
+				t.Errorf("data differs: got %s want %s", gotData, wantData)
+			}
+		})
+	}
+}
+
+func TestSurfaceProtocolOrdering(t *testing.T) {
+	s := NewSurface("s")
+	s.Add(TextStatic("root", "x"))
+	s.SetData("/v", 1)
+
+	if s.Protocol() != ProtocolDefault {
+		t.Errorf("expected default protocol, got %q", s.Protocol())
+	}
+	if msgs := s.Messages(); msgs[0].Kind() != MessageKindBeginRendering {
+		t.Errorf("expected beginRendering first by default, got %v", msgs[0].Kind())
+	}
+
+	s.SetProtocol(ProtocolV08)
+	msgs := s.Messages()
+	if msgs[0].Kind() != MessageKindUpdateComponents || msgs[2].Kind() != MessageKindBeginRendering {
+		t.Errorf("expected v0.8 order updateComponents, dataModelUpdate, beginRendering, got %v %v %v",
+			msgs[0].Kind(), msgs[1].Kind(), msgs[2].Kind())
+	}
+}
+
+func TestV08RemovalRoundTrip(t *testing.T) {
+	s := NewSurface("s").SetProtocol(ProtocolV08)
+	s.Add(TextBound("root", "/user/name"))
+	s.SetData("/user/name", "Ada")
+	s.SetData("/user/age", 36)
+	s.SetData("/user/tags", []any{"a", nil})
+	msgs := s.Messages()
+	s.Batch(func(b *Batch) { b.Data().Delete("/user/age") })
+	msgs = append(msgs, s.Flush()...)
+
+	out := encodeAll(t, ProtocolV08, msgs)
+	if strings.Contains(out, `"valueString":""`) {
+		t.Errorf("expected no empty strings for nil values:\n%s", out)
+	}
+	decoded, err := ReadJSONL(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClientState()
+	if err := c.ApplyAll(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Data("s", "/user/age"); ok {
+		t.Errorf("expected /user/age removed, got %v", v)
+	}
+	if v, _ := c.Data("s", "/user/name"); v != "Ada" {
+		t.Errorf("expected name kept, got %v", v)
+	}
+	if v, _ := c.Data("s", "/user/tags"); !reflect.DeepEqual(v, []any{"a", nil}) {
+		t.Errorf("expected null element kept, got %v", v)
+	}
+}
+
+func TestV09RemovalRoundTrip(t *testing.T) {
+	update := Message{DataModelUpdate: &DataModelUpdate{SurfaceID: "s", Contents: map[string]any{
+		"/user/age": nil, "/user/name": "Ada",
+	}}}
+	lines, err := EncodeMessage(update, ProtocolV09)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(lines[0]); got != `{"updateDataModel":{"path":"/user/age","surfaceId":"s"}}` {
+		t.Errorf("expected removal without a value, got %s", got)
+	}
+
+	c := NewClientState()
+	c.Apply(Message{DataModelUpdate: &DataModelUpdate{SurfaceID: "s", Contents: map[string]any{"/user/age": 36}}})
+	for _, line := range lines {
+		msg, err := decodeMessage(line, DefaultRegistry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := msg.DataModelUpdate.Contents["/user/age"]; ok && v != nil {
+			t.Errorf("expected a removal, got %v", v)
+		}
+		if err := c.Apply(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v, ok := c.Data("s", "/user/age"); ok {
+		t.Errorf("expected /user/age removed, got %v", v)
+	}
+	if v, _ := c.Data("s", "/user/name"); v != "Ada" {
+		t.Errorf("expected name set, got %v", v)
+	}
+}
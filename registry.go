@@ -0,0 +1,136 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// StandardComponents lists the component types defined by the A2UI spec.
+var StandardComponents = []string{
+	"Column", "Row", "Card", "List", "Tabs", "Modal",
+	"Text", "Image", "Icon", "Video", "AudioPlayer", "Divider",
+	"Button", "TextField", "CheckBox", "DateTimeInput", "MultipleChoice", "Slider",
+}
+
+// DefaultRegistry is used by decoders and surfaces without an explicit registry.
+// It knows the standard components; register custom components on it to make
+// them available package-wide, typically from init functions.
+var DefaultRegistry = NewRegistry()
+
+// Registry maps component type names (the "component" field) to Go types.
+// Decoding, validation and cloning use it so that custom components
+// round-trip as their real types instead of generic maps. A Registry is
+// safe for concurrent use, so components may be registered while other
+// goroutines decode.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewRegistry creates a registry with all standard components registered as Component.
+func NewRegistry() *Registry {
+	r := &Registry{types: make(map[string]reflect.Type)}
+	for _, name := range StandardComponents {
+		r.types[name] = componentType
+	}
+	return r
+}
+
+// Register associates a component type name with the Go type of prototype.
+// The prototype must be a Component or a struct (or pointer to struct) that
+// embeds Component. Decoded values have the same type as the prototype.
+// Registering a name again replaces the previous type.
+func (r *Registry) Register(name string, prototype any) error {
+	if name == "" {
+		return fmt.Errorf("a2ui: register: empty component name")
+	}
+	if asComponent(prototype) == nil {
+		return fmt.Errorf("a2ui: register %s: %T does not embed Component", name, prototype)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[name] = reflect.TypeOf(prototype)
+	return nil
+}
+
+// Lookup returns the Go type registered for name.
+func (r *Registry) Lookup(name string) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// Known reports whether name is registered.
+func (r *Registry) Known(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.types[name]
+	return ok
+}
+
+// Names returns all registered component type names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Decode decodes a single component. The result has the type registered for
+// its "component" field, or Component if the name is unknown.
+func (r *Registry) Decode(data []byte) (any, error) {
+	var head struct {
+		Component string `json:"component"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+
+	t, ok := r.Lookup(head.Component)
+	if !ok {
+		t = componentType
+	}
+	return decodeAs(data, t)
+}
+
+// Clone returns a deep copy of the component c. Components whose name is
+// registered are cloned into the registered type; otherwise the copy keeps
+// the type of c, or becomes a Component if c does not embed one (for
+// example a map[string]any).
+func (r *Registry) Clone(c any) (any, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if comp := asComponent(c); comp != nil {
+		if !r.Known(comp.Component) {
+			return decodeAs(data, reflect.TypeOf(c))
+		}
+	}
+	return r.Decode(data)
+}
+
+// decodeAs decodes data into a new value of type t.
+func decodeAs(data []byte, t reflect.Type) (any, error) {
+	if t.Kind() == reflect.Pointer {
+		v := reflect.New(t.Elem())
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,218 @@
+package a2ui
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRegistryStandardComponents(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range StandardComponents {
+		typ, ok := r.Lookup(name)
+		if !ok {
+			t.Errorf("expected %s to be registered", name)
+			continue
+		}
+		if typ != reflect.TypeOf(Component{}) {
+			t.Errorf("expected %s to map to Component, got %v", name, typ)
+		}
+	}
+	if len(r.Names()) != len(StandardComponents) {
+		t.Errorf("expected %d names, got %d", len(StandardComponents), len(r.Names()))
+	}
+}
+
+func TestRegistryRegisterRejectsInvalid(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("", Gauge{}); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if err := r.Register("Thing", struct{ Name string }{}); err == nil {
+		t.Error("expected error for type without embedded Component")
+	}
+	if err := r.Register("Gauge", Gauge{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !r.Known("Gauge") {
+		t.Error("expected Gauge to be known")
+	}
+}
+
+func TestDecoderWithRegistry(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("Gauge", Gauge{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("SparklineChart", &SparklineChart{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSurface("dash")
+	s.Add(Column("root", "gauge", "chart"))
+	s.Add(Gauge{Component: Component{ID: "gauge", Component: "Gauge"}, Color: "red", Thresholds: []int{1, 2}})
+	s.Add(SparklineChart{Component: Component{ID: "chart", Component: "SparklineChart"}, Data: []float64{1.5}, Height: 10})
+
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, s.Messages()); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(&buf).SetRegistry(r)
+	if _, err := dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comps := msg.UpdateComponents.Components
+	if _, ok := comps[0].(Component); !ok {
+		t.Errorf("expected Component, got %T", comps[0])
+	}
+	g, ok := comps[1].(Gauge)
+	if !ok {
+		t.Fatalf("expected Gauge, got %T", comps[1])
+	}
+	if g.ID != "gauge" || g.Color != "red" || len(g.Thresholds) != 2 {
+		t.Errorf("unexpected gauge: %+v", g)
+	}
+	chart, ok := comps[2].(*SparklineChart)
+	if !ok {
+		t.Fatalf("expected *SparklineChart, got %T", comps[2])
+	}
+	if chart.Height != 10 || len(chart.Data) != 1 {
+		t.Errorf("unexpected chart: %+v", chart)
+	}
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	r := NewRegistry()
+	data := []byte(`{"id":"g","component":"Gauge","color":"red"}`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := r.Register(fmt.Sprintf("Custom%d_%d", i, j), Gauge{}); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := r.Decode(data); err != nil {
+					t.Error(err)
+				}
+				r.Names()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(r.Names()); n != len(StandardComponents)+200 {
+		t.Errorf("expected %d names, got %d", len(StandardComponents)+200, n)
+	}
+}
+
+func TestDecoderUnregisteredCustomBecomesComponent(t *testing.T) {
+	input := `{"updateComponents":{"surfaceId":"s","components":[{"id":"g","component":"Gauge","label":"L","color":"red"}]}}`
+
+	msgs, err := ReadJSONL(bytes.NewBufferString(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := msgs[0].UpdateComponents.Components[0].(Component)
+	if !ok || c.Label != "L" {
+		t.Errorf("expected Component with label, got %#v", msgs[0].UpdateComponents.Components[0])
+	}
+}
+
+func TestRegistryClone(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("Gauge", Gauge{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Generic maps become their registered type
+	c, err := r.Clone(map[string]any{"id": "g", "component": "Gauge", "color": "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, ok := c.(Gauge); !ok || g.Color != "blue" {
+		t.Errorf("expected Gauge with color blue, got %#v", c)
+	}
+
+	// Unregistered custom types keep their own type
+	orig := SparklineChart{Component: Component{ID: "s", Component: "SparklineChart"}, Data: []float64{1, 2}}
+	c, err = r.Clone(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, ok := c.(SparklineChart)
+	if !ok {
+		t.Fatalf("expected SparklineChart, got %T", c)
+	}
+	clone.Data[0] = 99
+	if orig.Data[0] != 1 {
+		t.Error("expected clone to be deep")
+	}
+}
+
+func TestSurfaceClone(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "g"))
+	s.Add(Gauge{Component: Component{ID: "g", Component: "Gauge"}, Thresholds: []int{1}})
+	s.SetData("/v", 1)
+
+	c, err := s.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add(Column("root", "g", "x"))
+	g, _ := c.Get("g")
+	g.(Gauge).Thresholds[0] = 5
+
+	root, _ := s.Get("root")
+	if len(root.(Component).Children) != 1 {
+		t.Error("expected original root to be unchanged")
+	}
+	orig, _ := s.Get("g")
+	if orig.(Gauge).Thresholds[0] != 1 {
+		t.Error("expected original custom component to be unchanged")
+	}
+	if len(c.PendingUpdates()) != 2 {
+		t.Errorf("expected clone to keep pending state")
+	}
+}
+
+func TestValidateUnknownComponentType(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "chart"))
+	s.Add(Component{ID: "chart", Component: "Chart"})
+
+	if errs := s.Validate(); len(errs) != 0 {
+		t.Errorf("expected no errors without registry, got %v", errs)
+	}
+
+	s.SetRegistry(NewRegistry())
+	errs := s.Validate()
+	if len(errs) != 1 || errs[0].ComponentID != "chart" || errs[0].Field != "Component" {
+		t.Errorf("expected unknown type error for chart, got %v", errs)
+	}
+
+	r := NewRegistry()
+	if err := r.Register("Chart", Component{}); err != nil {
+		t.Fatal(err)
+	}
+	s.SetRegistry(r)
+	if errs := s.Validate(); len(errs) != 0 {
+		t.Errorf("expected no errors with Chart registered, got %v", errs)
+	}
+}
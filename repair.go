@@ -0,0 +1,595 @@
+package a2ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RepairChange records one change Repair made to its input.
+type RepairChange struct {
+	ComponentID string // the component's ID after repair
+	Field       string // JSON property, e.g. "children[1]" or "action.type"
+	Message     string
+}
+
+func (c RepairChange) String() string {
+	if c.Field == "" {
+		return fmt.Sprintf("%s: %s", c.ComponentID, c.Message)
+	}
+	return fmt.Sprintf("%s: %s - %s", c.ComponentID, c.Field, c.Message)
+}
+
+// RepairReport lists the changes Repair made, in input order.
+type RepairReport struct {
+	Changes []RepairChange
+}
+
+// String returns one change per line.
+func (r *RepairReport) String() string {
+	lines := make([]string, len(r.Changes))
+	for i, c := range r.Changes {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Repairer turns loosely formed component JSON, such as the output of a
+// language model, into a surface.
+type Repairer struct {
+	registry *Registry
+}
+
+// NewRepairer creates a repairer that uses DefaultRegistry.
+func NewRepairer() *Repairer {
+	return &Repairer{registry: DefaultRegistry}
+}
+
+// SetRegistry sets the registry used to look up component types.
+func (r *Repairer) SetRegistry(reg *Registry) *Repairer {
+	r.registry = reg
+	return r
+}
+
+// Repair repairs data using DefaultRegistry. See Repairer.Repair.
+func Repair(surfaceID string, data []byte) (*Surface, *RepairReport, error) {
+	return NewRepairer().Repair(surfaceID, data)
+}
+
+// Repair parses data into a surface and reports every change it made to
+// get there. data may be an array of components, an object with a
+// "components" array, an updateComponents message or a single component
+// with nested children, optionally wrapped in a Markdown code fence.
+//
+// Repair flattens components nested in child references into the
+// adjacency list, generates missing IDs and renames duplicates, converts
+// v0.8 components, matches component types, property names and enum
+// values ignoring case and separators, and coerces values to their
+// property types (for example "true" to a boolean or a single child ID to
+// a list). Properties the component type does not have and values that
+// cannot be coerced are dropped. If no component has the ID "root", the
+// first top-level component becomes the root.
+//
+// An empty surfaceID uses the surfaceId of the input, if any. The result
+// is not validated; call Validate on it. Repair only fails if data is not
+// JSON or holds no components.
+func (r *Repairer) Repair(surfaceID string, data []byte) (*Surface, *RepairReport, error) {
+	var input any
+	if err := json.Unmarshal(stripCodeFence(data), &input); err != nil {
+		return nil, nil, fmt.Errorf("a2ui: repair: %w", err)
+	}
+	items, inputID, err := repairItems(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if surfaceID == "" {
+		surfaceID = inputID
+	}
+
+	p := &repairer{
+		registry: r.registry,
+		report:   &RepairReport{},
+		ids:      make(map[string]bool),
+		used:     make(map[string]bool),
+		counters: make(map[string]int),
+	}
+	collectIDs(input, p.ids)
+
+	var top []string
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			p.note("", fmt.Sprintf("[%d]", i), "dropped %s that is not a component", jsonKind(item))
+			continue
+		}
+		top = append(top, p.component(obj))
+	}
+	if len(top) == 0 {
+		return nil, nil, errors.New("a2ui: repair: no components")
+	}
+
+	s := NewSurface(surfaceID)
+	if r.registry != DefaultRegistry {
+		s.SetRegistry(r.registry)
+	}
+	for _, obj := range p.out {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, nil, fmt.Errorf("a2ui: repair %v: %w", obj["id"], err)
+		}
+		c, err := r.registry.Decode(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("a2ui: repair %v: %w", obj["id"], err)
+		}
+		s.Add(c)
+	}
+	if _, ok := s.Get(s.Root()); !ok {
+		s.SetRoot(top[0])
+		p.note(top[0], "", "used as the root")
+	}
+	return s, p.report, nil
+}
+
+// stripCodeFence removes a Markdown code fence around data.
+func stripCodeFence(data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("```")) {
+		return data
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return bytes.TrimSuffix(bytes.TrimSpace(data), []byte("```"))
+}
+
+// repairItems returns the components of input and its surface ID.
+func repairItems(input any) ([]any, string, error) {
+	switch v := input.(type) {
+	case []any:
+		return v, "", nil
+	case map[string]any:
+		if msg, ok := v["updateComponents"].(map[string]any); ok {
+			v = msg
+		}
+		id, _ := v["surfaceId"].(string)
+		if items, ok := v["components"].([]any); ok {
+			return items, id, nil
+		}
+		for _, key := range []string{"id", "component", "type"} {
+			if _, ok := v[key]; ok {
+				return []any{v}, "", nil
+			}
+		}
+	}
+	return nil, "", errors.New("a2ui: repair: no components found")
+}
+
+// collectIDs adds every string "id" in input to ids, so that generated IDs
+// do not collide with IDs defined later in the input.
+func collectIDs(input any, ids map[string]bool) {
+	switch v := input.(type) {
+	case map[string]any:
+		if id, ok := v["id"].(string); ok {
+			ids[id] = true
+		}
+		for _, child := range v {
+			collectIDs(child, ids)
+		}
+	case []any:
+		for _, child := range v {
+			collectIDs(child, ids)
+		}
+	}
+}
+
+// repairer holds the state of one Repair call.
+type repairer struct {
+	registry *Registry
+	report   *RepairReport
+	ids      map[string]bool // IDs found in the input
+	used     map[string]bool // IDs assigned so far
+	counters map[string]int  // last generated number per ID prefix
+	out      []map[string]any
+}
+
+func (p *repairer) note(id, field, format string, args ...any) {
+	p.report.Changes = append(p.report.Changes, RepairChange{
+		ComponentID: id,
+		Field:       field,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+// component repairs obj in place, adds it and the components nested in it
+// to p.out and returns its ID.
+func (p *repairer) component(obj map[string]any) string {
+	p.out = append(p.out, obj)
+	start := len(p.report.Changes)
+
+	if nested, ok := obj["component"].(map[string]any); ok {
+		p.convertV08(obj, nested)
+	}
+	if _, ok := obj["component"]; !ok {
+		if typ, ok := obj["type"]; ok {
+			obj["component"] = typ
+			delete(obj, "type")
+			p.note("", "component", "renamed 'type' to 'component'")
+		}
+	}
+	typ, _ := obj["component"].(string)
+	if !p.registry.Known(typ) {
+		if name := matchName(typ, p.registry.Names()); name != "" {
+			obj["component"] = name
+			p.note("", "component", "replaced '%s' with '%s'", typ, name)
+			typ = name
+		}
+	}
+
+	id := p.assignID(obj, typ)
+	for i := start; i < len(p.report.Changes); i++ {
+		p.report.Changes[i].ComponentID = id
+	}
+
+	fields := p.componentFields(typ)
+	p.moveChildren(id, obj, fields)
+	p.fixKeys(id, obj, fields, "")
+	p.flatten(id, obj)
+	p.coerceFields(id, obj, fields, "")
+	return id
+}
+
+// convertV08 rewrites a v0.8 component {"id", "component": {"<Type>": {...}}}
+// into the flat shape.
+func (p *repairer) convertV08(obj, nested map[string]any) {
+	raw := make(map[string]json.RawMessage, len(nested))
+	for k, v := range nested {
+		raw[k], _ = json.Marshal(v)
+	}
+	flatJSON, err := componentFromV08("", raw)
+	if err != nil {
+		return
+	}
+	var flat map[string]any
+	if json.Unmarshal(flatJSON, &flat) != nil {
+		return
+	}
+	id, hasID := obj["id"]
+	for k := range obj {
+		delete(obj, k)
+	}
+	for k, v := range flat {
+		obj[k] = v
+	}
+	delete(obj, "id")
+	if hasID {
+		obj["id"] = id
+	}
+	p.note("", "component", "converted v0.8 component")
+}
+
+// assignID makes obj's ID a unique string and returns it.
+func (p *repairer) assignID(obj map[string]any, typ string) string {
+	var id string
+	switch v := obj["id"].(type) {
+	case string:
+		id = v
+	case float64:
+		id = strconv.FormatFloat(v, 'f', -1, 64)
+		p.note("", "id", "converted number %s to string", id)
+	}
+
+	switch {
+	case id == "":
+		prefix := strings.ToLower(typ)
+		if prefix == "" {
+			prefix = "component"
+		}
+		id = p.newID(prefix)
+		p.note("", "id", "generated id '%s'", id)
+	case p.used[id]:
+		old := id
+		id = p.newID(old)
+		p.note("", "id", "renamed duplicate id '%s' to '%s'", old, id)
+	}
+	p.used[id] = true
+	obj["id"] = id
+	return id
+}
+
+// newID returns prefix-N for the lowest N not used by any component.
+func (p *repairer) newID(prefix string) string {
+	for {
+		p.counters[prefix]++
+		id := fmt.Sprintf("%s-%d", prefix, p.counters[prefix])
+		if !p.ids[id] && !p.used[id] {
+			return id
+		}
+	}
+}
+
+// componentFields returns the properties of the component type typ.
+// Standard types only have the properties they use.
+func (p *repairer) componentFields(typ string) map[string]jsonField {
+	t, ok := p.registry.Lookup(typ)
+	if !ok {
+		t = componentType
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fields := make(map[string]jsonField)
+	for _, f := range jsonFields(t) {
+		fields[f.name] = f
+	}
+
+	spec, ok := componentSpecs[typ]
+	if !ok || t != componentType {
+		return fields
+	}
+	used := map[string]jsonField{"id": fields["id"], "component": fields["component"]}
+	for _, name := range spec.properties {
+		used[name] = fields[name]
+	}
+	return used
+}
+
+// moveChildren turns "children" with a single entry into "child" for
+// component types that only have a child, and "child" into "children" for
+// those that only have children.
+func (p *repairer) moveChildren(id string, obj map[string]any, fields map[string]jsonField) {
+	_, hasChild := fields["child"]
+	_, hasChildren := fields["children"]
+	switch {
+	case hasChild && !hasChildren && obj["child"] == nil:
+		if children, ok := obj["children"].([]any); ok && len(children) == 1 {
+			obj["child"] = children[0]
+			delete(obj, "children")
+			p.note(id, "child", "moved the only entry of 'children' to 'child'")
+		}
+	case hasChildren && !hasChild && obj["children"] == nil:
+		if child, ok := obj["child"]; ok {
+			obj["children"] = []any{child}
+			delete(obj, "child")
+			p.note(id, "children", "moved 'child' to 'children'")
+		}
+	}
+}
+
+// fixKeys renames keys of obj that match a field ignoring case and
+// separators, and drops keys that match none.
+func (p *repairer) fixKeys(id string, obj map[string]any, fields map[string]jsonField, prefix string) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	for _, key := range sortedPaths(obj) {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		value := obj[key]
+		delete(obj, key)
+		name := matchName(key, names)
+		if _, taken := obj[name]; name == "" || taken {
+			p.note(id, prefix+key, "dropped unknown property")
+			continue
+		}
+		obj[name] = value
+		p.note(id, prefix+name, "renamed '%s' to '%s'", key, name)
+	}
+}
+
+// flatten replaces components nested in child references with their IDs.
+func (p *repairer) flatten(id string, obj map[string]any) {
+	for _, key := range []string{"child", "template", "entryPointChild", "contentChild"} {
+		if nested, ok := obj[key].(map[string]any); ok {
+			obj[key] = p.nested(id, key, nested)
+		}
+	}
+	if nested, ok := obj["children"].(map[string]any); ok {
+		obj["children"] = []any{nested}
+	}
+	if children, ok := obj["children"].([]any); ok {
+		for i, child := range children {
+			if nested, ok := child.(map[string]any); ok {
+				children[i] = p.nested(id, fmt.Sprintf("children[%d]", i), nested)
+			}
+		}
+	}
+	if tabs, ok := obj["tabs"].([]any); ok {
+		for i, tab := range tabs {
+			tab, _ := tab.(map[string]any)
+			if nested, ok := tab["child"].(map[string]any); ok {
+				tab["child"] = p.nested(id, fmt.Sprintf("tabs[%d].child", i), nested)
+			}
+		}
+	}
+}
+
+func (p *repairer) nested(parent, field string, obj map[string]any) string {
+	id := p.component(obj)
+	p.note(parent, field, "replaced nested component with reference '%s'", id)
+	return id
+}
+
+// coerceFields coerces the values of obj to the types of fields, dropping
+// those that cannot be coerced.
+func (p *repairer) coerceFields(id string, obj map[string]any, fields map[string]jsonField, prefix string) {
+	for _, key := range sortedPaths(obj) {
+		f := fields[key]
+		if v, ok := p.coerce(id, prefix+key, obj[key], f.typ, f.enum()); ok {
+			obj[key] = v
+		} else {
+			delete(obj, key)
+		}
+	}
+}
+
+var iconNameType = reflect.TypeOf(IconName(""))
+
+// stringShorthands expands a string given in place of a struct.
+var stringShorthands = map[reflect.Type]func(s string) map[string]any{
+	reflect.TypeOf(DataBinding{}):  func(s string) map[string]any { return map[string]any{"path": s} },
+	reflect.TypeOf(Action{}):       func(s string) map[string]any { return map[string]any{"type": s} },
+	reflect.TypeOf(ChoiceOption{}): func(s string) map[string]any { return map[string]any{"label": s, "value": s} },
+}
+
+// coerce converts v to the JSON form of t. It reports false if the value
+// must be dropped.
+func (p *repairer) coerce(id, field string, v any, t reflect.Type, enum []string) (any, bool) {
+	if v == nil {
+		p.note(id, field, "dropped null value")
+		return nil, false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if enum != nil {
+		s, _ := v.(string)
+		if containsString(enum, s) {
+			return s, true
+		}
+		if name := matchName(s, enum); name != "" {
+			p.note(id, field, "replaced '%s' with '%s'", s, name)
+			return name, true
+		}
+		if _, isString := v.(string); isString && s != "" && t == iconNameType {
+			// Icons are an open set: Validate only warns about unknown names
+			p.note(id, field, "kept unknown icon '%s'", s)
+			return s, true
+		}
+		p.note(id, field, "dropped unknown value %s", jsonString(v))
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		switch x := v.(type) {
+		case string:
+			return x, true
+		case float64, bool:
+			s := jsonString(x)
+			p.note(id, field, "converted %s to string", jsonKind(x))
+			return s, true
+		}
+
+	case reflect.Bool:
+		switch x := v.(type) {
+		case bool:
+			return x, true
+		case string:
+			switch strings.ToLower(strings.TrimSpace(x)) {
+			case "true", "yes", "on", "1":
+				p.note(id, field, "converted %s to true", jsonString(x))
+				return true, true
+			case "false", "no", "off", "0", "":
+				p.note(id, field, "converted %s to false", jsonString(x))
+				return false, true
+			}
+		case float64:
+			if x == 0 || x == 1 {
+				p.note(id, field, "converted %g to %t", x, x == 1)
+				return x == 1, true
+			}
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, ok := toFloat(v); ok {
+			n := math.Round(f)
+			if _, isNumber := v.(float64); !isNumber || n != f {
+				p.note(id, field, "converted %s to %g", jsonString(v), n)
+			}
+			return n, true
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(v); ok {
+			if _, isNumber := v.(float64); !isNumber {
+				p.note(id, field, "converted %s to %g", jsonString(v), f)
+			}
+			return f, true
+		}
+
+	case reflect.Slice, reflect.Array:
+		list, ok := v.([]any)
+		if !ok {
+			list = []any{v}
+			p.note(id, field, "wrapped single value in an array")
+		}
+		out := make([]any, 0, len(list))
+		for i, item := range list {
+			if item, ok := p.coerce(id, fmt.Sprintf("%s[%d]", field, i), item, t.Elem(), enumValues[t.Elem()]); ok {
+				out = append(out, item)
+			}
+		}
+		return out, true
+
+	case reflect.Map:
+		if m, ok := v.(map[string]any); ok {
+			if t.Elem().Kind() != reflect.Interface {
+				for _, key := range sortedPaths(m) {
+					if item, ok := p.coerce(id, field+"."+key, m[key], t.Elem(), enumValues[t.Elem()]); ok {
+						m[key] = item
+					} else {
+						delete(m, key)
+					}
+				}
+			}
+			return m, true
+		}
+
+	case reflect.Struct:
+		if s, ok := v.(string); ok {
+			if expand, ok := stringShorthands[t]; ok {
+				p.note(id, field, "expanded %s to an object", jsonString(s))
+				v = expand(s)
+			}
+		}
+		if m, ok := v.(map[string]any); ok {
+			fields := make(map[string]jsonField)
+			for _, f := range jsonFields(t) {
+				fields[f.name] = f
+			}
+			p.fixKeys(id, m, fields, field+".")
+			p.coerceFields(id, m, fields, field+".")
+			return m, true
+		}
+
+	case reflect.Interface:
+		return v, true
+	}
+
+	p.note(id, field, "dropped %s value", jsonKind(v))
+	return nil, false
+}
+
+// matchName returns the candidate equal to s ignoring case, "-", "_" and
+// spaces, or "".
+func matchName(s string, candidates []string) string {
+	key := normalizeName(s)
+	if key == "" {
+		return ""
+	}
+	for _, c := range candidates {
+		if normalizeName(c) == key {
+			return c
+		}
+	}
+	return ""
+}
+
+func normalizeName(s string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(s))
+}
+
+// jsonString formats v as JSON.
+func jsonString(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,166 @@
+package a2ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func hasRepairChange(r *RepairReport, componentID, field, message string) bool {
+	for _, c := range r.Changes {
+		if c.ComponentID == componentID && c.Field == field && c.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRepairNestedTree(t *testing.T) {
+	input := "```json\n" + `{
+		"type": "column",
+		"id": "root",
+		"children": [
+			{"component": "Text", "text": "Title", "usageHint": "H1", "color": "red"},
+			{"component": "Card", "children": [{"component": "Text", "text": 42}]},
+			{"component": "Button", "child": {"component": "Text", "text": "Go"}, "action": "submit", "primary": "true"},
+			"footer"
+		]
+	}` + "\n```"
+
+	s, report, err := Repair("main", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := s.Validate(); len(errs) != 1 || errs[0].Message != "child 'footer' not found" {
+		t.Errorf("expected only the dangling reference to remain, got %v", errs)
+	}
+
+	root, _ := s.Get("root")
+	if got := root.(Component).Children; strings.Join(got, ",") != "text-1,card-1,button-1,footer" {
+		t.Errorf("unexpected root children: %v", got)
+	}
+	title, _ := s.Get("text-1")
+	if c := title.(Component); c.UsageHint != UsageHintH1 || c.Text != "Title" {
+		t.Errorf("unexpected title: %+v", c)
+	}
+	card, _ := s.Get("card-1")
+	if c := card.(Component); c.Child != "text-2" || c.Children != nil {
+		t.Errorf("expected card children moved to child, got %+v", c)
+	}
+	inner, _ := s.Get("text-2")
+	if inner.(Component).Text != "42" {
+		t.Errorf("expected number coerced to string, got %+v", inner)
+	}
+	button, _ := s.Get("button-1")
+	if c := button.(Component); !c.Primary || c.Action == nil || c.Action.Type != "submit" || c.Child != "text-3" {
+		t.Errorf("unexpected button: %+v", c)
+	}
+
+	tests := []struct{ id, field, message string }{
+		{"root", "component", "renamed 'type' to 'component'"},
+		{"text-1", "id", "generated id 'text-1'"},
+		{"text-1", "usageHint", "replaced 'H1' with 'h1'"},
+		{"text-1", "color", "dropped unknown property"},
+		{"card-1", "child", "moved the only entry of 'children' to 'child'"},
+		{"text-2", "text", "converted number to string"},
+		{"button-1", "action", `expanded "submit" to an object`},
+		{"button-1", "primary", `converted "true" to true`},
+		{"root", "children[0]", "replaced nested component with reference 'text-1'"},
+	}
+	for _, tt := range tests {
+		if !hasRepairChange(report, tt.id, tt.field, tt.message) {
+			t.Errorf("expected %s: %s - %s, got\n%s", tt.id, tt.field, tt.message, report)
+		}
+	}
+}
+
+func TestRepairComponentList(t *testing.T) {
+	input := `{"updateComponents": {"surfaceId": "form", "components": [
+		{"id": "main", "component": "column", "Children": "name"},
+		{"id": "name", "component": "TextField", "label": "Name", "textFieldType": "password"},
+		{"id": "name", "component": "Slider", "minValue": "1", "maxValue": 10.5, "value": 3},
+		{"id": 7, "component": {"Text": {"text": {"literalString": "v0.8"}}}},
+		"stray"
+	]}}`
+
+	s, report, err := Repair("", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID() != "form" || s.Root() != "main" {
+		t.Errorf("expected surface 'form' rooted at 'main', got %s %s", s.ID(), s.Root())
+	}
+	if !hasRepairChange(report, "main", "", "used as the root") {
+		t.Errorf("expected root change, got %v", report)
+	}
+
+	main, _ := s.Get("main")
+	if c := main.(Component); c.Component != "Column" || len(c.Children) != 1 {
+		t.Errorf("unexpected column: %+v", c)
+	}
+	if !hasRepairChange(report, "main", "children", "wrapped single value in an array") {
+		t.Errorf("expected single child wrapped, got %v", report)
+	}
+	field, _ := s.Get("name")
+	if field.(Component).TextFieldType != "" {
+		t.Errorf("expected unknown enum value dropped, got %+v", field)
+	}
+	slider, ok := s.Get("name-1")
+	if !ok || slider.(Component).MinValue != 1 {
+		t.Errorf("expected duplicate renamed with coerced minValue, got %+v", slider)
+	}
+	text, ok := s.Get("7")
+	if !ok || text.(Component).Text != "v0.8" {
+		t.Errorf("expected converted v0.8 component, got %+v", text)
+	}
+	if !hasRepairChange(report, "", "[4]", "dropped string that is not a component") {
+		t.Errorf("expected non-component entry dropped, got %v", report)
+	}
+}
+
+func TestRepairKeepsUnknownIcons(t *testing.T) {
+	s, report, err := Repair("s", []byte(`[
+		{"id": "root", "component": "Row", "children": ["rocket", "home"]},
+		{"id": "rocket", "component": "Icon", "icon": "rocket"},
+		{"id": "home", "component": "Icon", "icon": "Home"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := s.Get("rocket"); c.(Component).Icon != "rocket" {
+		t.Errorf("expected unknown icon kept, got %+v", c)
+	}
+	if !hasRepairChange(report, "rocket", "icon", "kept unknown icon 'rocket'") {
+		t.Errorf("expected kept icon reported, got %v", report)
+	}
+	if c, _ := s.Get("home"); c.(Component).Icon != "home" {
+		t.Errorf("expected icon matched to 'home', got %+v", c)
+	}
+	for _, e := range s.Validate() {
+		if e.Severity != SeverityWarning {
+			t.Errorf("expected only warnings, got %v", e)
+		}
+	}
+}
+
+func TestRepairCustomComponent(t *testing.T) {
+	r := NewRegistry()
+	r.Register("Gauge", Gauge{})
+	s, report, err := NewRepairer().SetRegistry(r).Repair("dash", []byte(
+		`[{"id": "root", "component": "gauge", "color": "red", "showLabel": 1, "thresholds": ["10", 20.4]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := s.Get("root")
+	g, ok := c.(Gauge)
+	if !ok || !g.ShowLabel || len(g.Thresholds) != 2 || g.Thresholds[0] != 10 || g.Thresholds[1] != 20 {
+		t.Fatalf("expected repaired Gauge, got %#v\n%s", c, report)
+	}
+}
+
+func TestRepairErrors(t *testing.T) {
+	for _, input := range []string{`{not json`, `{"hello": "world"}`, `[1, 2]`} {
+		if _, _, err := Repair("s", []byte(input)); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package a2ui
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// DefaultReplaySize is the number of messages a ReplayBuffer keeps when
+// created with a size of zero or less.
+const DefaultReplaySize = 256
+
+// SequencedMessage is a message with the sequence number a ReplayBuffer
+// assigned to it.
+type SequencedMessage struct {
+	Seq     uint64
+	Message Message
+}
+
+// ReplayBuffer numbers messages from 1 and keeps the most recent ones, so
+// a client that reconnects with the last number it saw can be sent what it
+// missed. Keep one buffer per client session, across its connections; see
+// SSEWriter.SetReplay. A ReplayBuffer is safe for concurrent use.
+type ReplayBuffer struct {
+	mu      sync.Mutex
+	size    int
+	last    uint64
+	entries []SequencedMessage
+}
+
+// NewReplayBuffer creates a buffer keeping the last size messages.
+func NewReplayBuffer(size int) *ReplayBuffer {
+	if size <= 0 {
+		size = DefaultReplaySize
+	}
+	return &ReplayBuffer{size: size}
+}
+
+// Append numbers messages and records them, dropping the oldest entries
+// beyond the buffer size.
+func (b *ReplayBuffer) Append(messages ...Message) []SequencedMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.append(messages)
+}
+
+func (b *ReplayBuffer) append(messages []Message) []SequencedMessage {
+	added := make([]SequencedMessage, len(messages))
+	for i, msg := range messages {
+		b.last++
+		added[i] = SequencedMessage{Seq: b.last, Message: msg}
+	}
+	b.entries = append(b.entries, added...)
+	if n := len(b.entries); n > b.size {
+		// Appending reallocates with only the kept window, bounding memory
+		b.entries = b.entries[n-b.size:]
+	}
+	return added
+}
+
+// LastSeq returns the sequence number of the last appended message, or 0.
+func (b *ReplayBuffer) LastSeq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// Since returns the messages after seq. It reports false if some of them
+// are no longer buffered or seq was never assigned, in which case the
+// client needs a snapshot instead.
+func (b *ReplayBuffer) Since(seq uint64) ([]SequencedMessage, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.since(seq)
+}
+
+func (b *ReplayBuffer) since(seq uint64) ([]SequencedMessage, bool) {
+	if seq > b.last {
+		return nil, false
+	}
+	first := b.last - uint64(len(b.entries)) + 1
+	if seq+1 < first {
+		return nil, false
+	}
+	missed := make([]SequencedMessage, b.last-seq)
+	copy(missed, b.entries[seq+1-first:])
+	return missed, true
+}
+
+// Resume returns the messages after seq if they are all buffered.
+// Otherwise it appends the messages returned by snapshot, typically the
+// full state of every surface (see SurfaceManager.Snapshot), and returns
+// those. snapshot is called with the buffer locked, so messages appended
+// concurrently are ordered before or after the whole snapshot.
+func (b *ReplayBuffer) Resume(seq uint64, snapshot func() []Message) []SequencedMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if missed, ok := b.since(seq); ok {
+		return missed
+	}
+	return b.append(snapshot())
+}
+
+// LastEventID returns the sequence number a reconnecting client reports
+// in the Last-Event-ID header, which browsers set for EventSource, or in
+// the lastEventId query parameter. It reports false if neither is a valid
+// number.
+func LastEventID(r *http.Request) (uint64, bool) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	seq, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
@@ -0,0 +1,141 @@
+package a2ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func deleteMessages(ids ...string) []Message {
+	var msgs []Message
+	for _, id := range ids {
+		msgs = append(msgs, Message{DeleteSurface: &DeleteSurface{SurfaceID: id}})
+	}
+	return msgs
+}
+
+func seqs(entries []SequencedMessage) []uint64 {
+	var out []uint64
+	for _, e := range entries {
+		out = append(out, e.Seq)
+	}
+	return out
+}
+
+func TestReplayBuffer(t *testing.T) {
+	b := NewReplayBuffer(3)
+	if added := b.Append(deleteMessages("a", "b")...); len(added) != 2 || added[1].Seq != 2 {
+		t.Fatalf("unexpected entries: %+v", added)
+	}
+	b.Append(deleteMessages("c", "d")...)
+	if b.LastSeq() != 4 {
+		t.Errorf("expected last seq 4, got %d", b.LastSeq())
+	}
+
+	tests := []struct {
+		seq  uint64
+		want []uint64
+		ok   bool
+	}{
+		{4, nil, true},
+		{2, []uint64{3, 4}, true},
+		{1, []uint64{2, 3, 4}, true},
+		{0, nil, false}, // message 1 was dropped
+		{5, nil, false}, // from another buffer
+	}
+	for _, tt := range tests {
+		got, ok := b.Since(tt.seq)
+		if ok != tt.ok || len(got) != len(tt.want) || (len(got) > 0 && got[0].Seq != tt.want[0]) {
+			t.Errorf("Since(%d) = %v, %v; want %v, %v", tt.seq, seqs(got), ok, tt.want, tt.ok)
+		}
+	}
+
+	snapshot := func() []Message { return deleteMessages("snap") }
+	if got := b.Resume(3, snapshot); len(got) != 1 || got[0].Message.SurfaceID() != "d" {
+		t.Errorf("expected missed message, got %+v", got)
+	}
+	got := b.Resume(0, snapshot)
+	if len(got) != 1 || got[0].Seq != 5 || got[0].Message.SurfaceID() != "snap" {
+		t.Errorf("expected snapshot as seq 5, got %+v", got)
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/events?lastEventId=7", nil)
+	if seq, ok := LastEventID(r); !ok || seq != 7 {
+		t.Errorf("expected 7 from query, got %d, %v", seq, ok)
+	}
+	r.Header.Set("Last-Event-ID", "12")
+	if seq, ok := LastEventID(r); !ok || seq != 12 {
+		t.Errorf("expected header to win, got %d, %v", seq, ok)
+	}
+	if _, ok := LastEventID(httptest.NewRequest(http.MethodGet, "/", nil)); ok {
+		t.Error("expected no ID")
+	}
+}
+
+func TestSSEWriterResume(t *testing.T) {
+	m := NewSurfaceManager()
+	s, _ := m.Create("s")
+	s.Add(TextStatic("root", "Hi"))
+	buf := NewReplayBuffer(2)
+
+	// First connection sees messages 1 and 2, then drops
+	rec := httptest.NewRecorder()
+	sse := NewSSEWriter(rec).SetReplay(buf)
+	sse.WriteMessages(m.Flush())
+	if !strings.HasPrefix(rec.Body.String(), "id: 1\ndata: {\"beginRendering\"") || sse.LastID() != 2 {
+		t.Fatalf("unexpected events: %q", rec.Body.String())
+	}
+
+	// Missed while disconnected
+	s.Add(TextStatic("root", "Hello"))
+	sse.WriteMessages(m.Flush())
+
+	rec = httptest.NewRecorder()
+	sse = NewSSEWriter(rec).SetReplay(buf)
+	if err := sse.Resume(2, m.Snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "id: 3\ndata: {\"updateComponents\":{\"surfaceId\":\"s\",\"components\":[{\"id\":\"root\",\"component\":\"Text\",\"text\":\"Hello\"}]}}\n\n" {
+		t.Errorf("unexpected replay: %q", got)
+	}
+
+	// Too far behind: a snapshot with new IDs
+	rec = httptest.NewRecorder()
+	sse = NewSSEWriter(rec).SetReplay(buf)
+	sse.Resume(0, m.Snapshot)
+	if got := rec.Body.String(); !strings.HasPrefix(got, "id: 4\ndata: {\"beginRendering\"") || !strings.Contains(got, "id: 5\n") {
+		t.Errorf("unexpected snapshot: %q", got)
+	}
+
+	// Lines written through Write are recorded too
+	rec = httptest.NewRecorder()
+	sse = NewSSEWriter(rec).SetReplay(buf)
+	WriteJSONL(sse, deleteMessages("s"))
+	if missed, _ := buf.Since(5); len(missed) != 1 || missed[0].Message.Kind() != MessageKindDeleteSurface {
+		t.Errorf("expected recorded line, got %+v", missed)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "id: 6\n") {
+		t.Errorf("unexpected event: %q", rec.Body.String())
+	}
+
+	if err := NewSSEWriter(httptest.NewRecorder()).Resume(0, m.Snapshot); err == nil {
+		t.Error("expected error without a replay buffer")
+	}
+}
+
+func TestSSEWriterLastIDSkipsEmptyMessages(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := NewSSEWriter(rec).SetProtocol(ProtocolV08).SetReplay(NewReplayBuffer(0))
+	sse.WriteMessages(deleteMessages("s"))
+
+	// An empty data update encodes to no lines in v0.8, so no id is sent
+	if err := sse.WriteMessage(Message{DataModelUpdate: &DataModelUpdate{SurfaceID: "s"}}); err != nil {
+		t.Fatal(err)
+	}
+	if sse.LastID() != 1 || strings.Contains(rec.Body.String(), "id: 2") {
+		t.Errorf("expected LastID 1 without a second event, got %d and %q", sse.LastID(), rec.Body.String())
+	}
+}
@@ -0,0 +1,253 @@
+package a2ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrNoHandler is returned by Dispatch when no handler matches an event
+// and no fallback handler is set.
+var ErrNoHandler = errors.New("a2ui: no handler for event")
+
+// DefaultMaxBodySize is the request body limit of an EventRouter in bytes
+// when none is set with SetMaxBodySize.
+const DefaultMaxBodySize = 1 << 20
+
+// EventContext carries an event and the surface it targets to a handler.
+type EventContext struct {
+	context.Context
+
+	// Event is the dispatched event.
+	Event *Event
+
+	// Surface is the surface with the event's SurfaceID, or nil if the
+	// router does not know it.
+	Surface *Surface
+
+	// Request is the HTTP request the event arrived with, or nil when
+	// dispatched directly (for example from a WebSocket session).
+	Request *http.Request
+}
+
+// EventHandler handles an event and returns the messages to send back.
+type EventHandler interface {
+	HandleEvent(ctx *EventContext) ([]Message, error)
+}
+
+// EventHandlerFunc adapts a function to EventHandler.
+type EventHandlerFunc func(ctx *EventContext) ([]Message, error)
+
+// HandleEvent calls f(ctx).
+func (f EventHandlerFunc) HandleEvent(ctx *EventContext) ([]Message, error) {
+	return f(ctx)
+}
+
+// EventPattern selects events by surface, component and event type.
+// Empty fields and "*" match any value.
+type EventPattern struct {
+	SurfaceID   string
+	ComponentID string
+	Type        string
+}
+
+// Match reports whether the pattern matches ev.
+func (p EventPattern) Match(ev *Event) bool {
+	return matchField(p.SurfaceID, ev.SurfaceID) &&
+		matchField(p.ComponentID, ev.ComponentID) &&
+		matchField(p.Type, ev.Type)
+}
+
+// specificity counts the non-wildcard fields of the pattern.
+func (p EventPattern) specificity() int {
+	n := 0
+	for _, f := range []string{p.SurfaceID, p.ComponentID, p.Type} {
+		if f != "" && f != "*" {
+			n++
+		}
+	}
+	return n
+}
+
+func matchField(pattern, value string) bool {
+	return pattern == "" || pattern == "*" || pattern == value
+}
+
+type eventRoute struct {
+	pattern EventPattern
+	handler EventHandler
+}
+
+// EventRouter dispatches ClientMessage events to handlers registered by
+// pattern. The most specific matching pattern wins; among equally specific
+// patterns the first registered wins. EventRouter is an http.Handler that
+// decodes the request body and writes the handler's messages as JSONL.
+type EventRouter struct {
+	mu       sync.RWMutex
+	routes   []eventRoute
+	surfaces map[string]*Surface
+	lookup   func(id string) *Surface
+	notFound EventHandler
+	protocol ProtocolVersion
+	maxBody  int64
+}
+
+// NewEventRouter creates an empty router.
+func NewEventRouter() *EventRouter {
+	return &EventRouter{surfaces: make(map[string]*Surface)}
+}
+
+// Handle registers h for events matching p.
+func (r *EventRouter) Handle(p EventPattern, h EventHandler) *EventRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, eventRoute{pattern: p, handler: h})
+	return r
+}
+
+// HandleFunc registers fn for events matching the given surface ID,
+// component ID and event type. Empty strings and "*" match any value.
+func (r *EventRouter) HandleFunc(surfaceID, componentID, eventType string, fn func(ctx *EventContext) ([]Message, error)) *EventRouter {
+	return r.Handle(EventPattern{SurfaceID: surfaceID, ComponentID: componentID, Type: eventType}, EventHandlerFunc(fn))
+}
+
+// AddSurface makes s available to handlers of events for its ID.
+func (r *EventRouter) AddSurface(s *Surface) *EventRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.surfaces[s.ID()] = s
+	return r
+}
+
+// SetSurfaceLookup sets a function used to find surfaces not added with AddSurface.
+func (r *EventRouter) SetSurfaceLookup(fn func(id string) *Surface) *EventRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookup = fn
+	return r
+}
+
+// SetNotFound sets the fallback handler for events no pattern matches.
+// Without one, Dispatch returns ErrNoHandler and ServeHTTP responds 404.
+func (r *EventRouter) SetNotFound(h EventHandler) *EventRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notFound = h
+	return r
+}
+
+// SetProtocol sets the protocol version ServeHTTP uses for responses.
+func (r *EventRouter) SetProtocol(v ProtocolVersion) *EventRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.protocol = v
+	return r
+}
+
+// SetMaxBodySize limits the request bodies ServeHTTP reads to n bytes;
+// larger requests get 413. A limit of zero or less uses DefaultMaxBodySize.
+func (r *EventRouter) SetMaxBodySize(n int64) *EventRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxBody = n
+	return r
+}
+
+// handlerFor returns the best matching handler and the event's surface.
+func (r *EventRouter) handlerFor(ev *Event) (EventHandler, *Surface) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best EventHandler
+	bestScore := -1
+	for _, route := range r.routes {
+		if !route.pattern.Match(ev) {
+			continue
+		}
+		if score := route.pattern.specificity(); score > bestScore {
+			best, bestScore = route.handler, score
+		}
+	}
+	if best == nil {
+		best = r.notFound
+	}
+
+	surface := r.surfaces[ev.SurfaceID]
+	if surface == nil && r.lookup != nil {
+		surface = r.lookup(ev.SurfaceID)
+	}
+	return best, surface
+}
+
+// Dispatch routes ev to its handler and returns the handler's messages.
+func (r *EventRouter) Dispatch(ctx context.Context, ev *Event) ([]Message, error) {
+	return r.dispatch(ctx, ev, nil)
+}
+
+func (r *EventRouter) dispatch(ctx context.Context, ev *Event, req *http.Request) ([]Message, error) {
+	h, surface := r.handlerFor(ev)
+	if h == nil {
+		return nil, ErrNoHandler
+	}
+	return h.HandleEvent(&EventContext{Context: ctx, Event: ev, Surface: surface, Request: req})
+}
+
+// ServeHTTP decodes a ClientMessage from the request body, dispatches its
+// event and writes the returned messages as JSONL. It responds 400 for
+// malformed requests, 413 for bodies over the limit (see SetMaxBodySize),
+// 404 when no handler matches and 500 on handler errors.
+func (r *EventRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.mu.RLock()
+	limit := r.maxBody
+	r.mu.RUnlock()
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+
+	var msg ClientMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, limit)).Decode(&msg); err != nil {
+		// http.MaxBytesError needs Go 1.19; the message is the same
+		if err.Error() == "http: request body too large" {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid client message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg.Event == nil {
+		http.Error(w, "client message has no event", http.StatusBadRequest)
+		return
+	}
+
+	messages, err := r.dispatch(req.Context(), msg.Event, req)
+	if errors.Is(err, ErrNoHandler) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r.mu.RLock()
+	protocol := r.protocol
+	r.mu.RUnlock()
+
+	// Encode before writing so invalid messages still produce a 500
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).SetProtocol(protocol).EncodeAll(messages); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Write(buf.Bytes())
+}
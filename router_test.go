@@ -0,0 +1,181 @@
+package a2ui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postEvent(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func reply(id string) []Message {
+	return []Message{{DeleteSurface: &DeleteSurface{SurfaceID: id}}}
+}
+
+func TestEventRouterMostSpecificWins(t *testing.T) {
+	r := NewEventRouter()
+	r.HandleFunc("", "", "", func(ctx *EventContext) ([]Message, error) { return reply("any"), nil })
+	r.HandleFunc("form", "*", "action", func(ctx *EventContext) ([]Message, error) { return reply("form"), nil })
+	r.HandleFunc("form", "submit", "action", func(ctx *EventContext) ([]Message, error) { return reply("submit"), nil })
+
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{SurfaceID: "form", ComponentID: "submit", Type: "action"}, "submit"},
+		{Event{SurfaceID: "form", ComponentID: "cancel", Type: "action"}, "form"},
+		{Event{SurfaceID: "other", ComponentID: "submit", Type: "action"}, "any"},
+	}
+	for _, tt := range tests {
+		msgs, err := r.Dispatch(context.Background(), &tt.event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := msgs[0].DeleteSurface.SurfaceID; got != tt.want {
+			t.Errorf("%+v: expected handler '%s', got '%s'", tt.event, tt.want, got)
+		}
+	}
+}
+
+func TestEventRouterNoHandler(t *testing.T) {
+	r := NewEventRouter()
+	r.HandleFunc("form", "", "", func(ctx *EventContext) ([]Message, error) { return nil, nil })
+
+	_, err := r.Dispatch(context.Background(), &Event{SurfaceID: "other"})
+	if !errors.Is(err, ErrNoHandler) {
+		t.Errorf("expected ErrNoHandler, got %v", err)
+	}
+
+	r.SetNotFound(EventHandlerFunc(func(ctx *EventContext) ([]Message, error) { return reply("fallback"), nil }))
+	msgs, err := r.Dispatch(context.Background(), &Event{SurfaceID: "other"})
+	if err != nil || msgs[0].DeleteSurface.SurfaceID != "fallback" {
+		t.Errorf("expected fallback handler, got %v %v", msgs, err)
+	}
+}
+
+func TestEventRouterSurfaceContext(t *testing.T) {
+	surface := NewSurface("form")
+	var got *Surface
+	r := NewEventRouter().AddSurface(surface)
+	r.HandleFunc("form", "", "", func(ctx *EventContext) ([]Message, error) {
+		got = ctx.Surface
+		return nil, nil
+	})
+
+	r.Dispatch(context.Background(), &Event{SurfaceID: "form"})
+	if got != surface {
+		t.Error("expected handler to receive the registered surface")
+	}
+
+	lookedUp := NewSurface("dynamic")
+	r.SetSurfaceLookup(func(id string) *Surface {
+		if id == "dynamic" {
+			return lookedUp
+		}
+		return nil
+	})
+	r.HandleFunc("dynamic", "", "", func(ctx *EventContext) ([]Message, error) {
+		got = ctx.Surface
+		return nil, nil
+	})
+	r.Dispatch(context.Background(), &Event{SurfaceID: "dynamic"})
+	if got != lookedUp {
+		t.Error("expected handler to receive the looked up surface")
+	}
+}
+
+func TestEventRouterServeHTTP(t *testing.T) {
+	r := NewEventRouter()
+	r.HandleFunc("form", "submit", "action", func(ctx *EventContext) ([]Message, error) {
+		if ctx.Request == nil {
+			t.Error("expected request in context")
+		}
+		s := NewSurface("done")
+		s.Add(TextStatic("root", "Thanks "+ctx.Event.Data["name"].(string)))
+		return s.Messages(), nil
+	})
+	r.HandleFunc("form", "fail", "", func(ctx *EventContext) ([]Message, error) {
+		return nil, errors.New("boom")
+	})
+
+	rec := postEvent(t, r, `{"event":{"surfaceId":"form","componentId":"submit","type":"action","data":{"name":"Ada"}}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected application/x-ndjson, got '%s'", ct)
+	}
+	msgs, err := ReadJSONL(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Kind() != MessageKindBeginRendering {
+		t.Errorf("unexpected response messages: %+v", msgs)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"Malformed", `{"event":`, http.StatusBadRequest},
+		{"NoEvent", `{}`, http.StatusBadRequest},
+		{"NoHandler", `{"event":{"surfaceId":"other"}}`, http.StatusNotFound},
+		{"HandlerError", `{"event":{"surfaceId":"form","componentId":"fail"}}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postEvent(t, r, tt.body); rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+		})
+	}
+
+	get := httptest.NewRecorder()
+	r.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if get.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", get.Code)
+	}
+}
+
+func TestEventRouterMaxBodySize(t *testing.T) {
+	r := NewEventRouter().SetMaxBodySize(64)
+	r.HandleFunc("", "", "", func(ctx *EventContext) ([]Message, error) { return nil, nil })
+
+	if rec := postEvent(t, r, `{"event":{"surfaceId":"s"}}`); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 below the limit, got %d", rec.Code)
+	}
+	big := `{"event":{"surfaceId":"s","data":{"text":"` + strings.Repeat("x", 100) + `"}}}`
+	if rec := postEvent(t, r, big); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 over the limit, got %d", rec.Code)
+	}
+
+	// The default limit applies without SetMaxBodySize
+	r = NewEventRouter()
+	r.HandleFunc("", "", "", func(ctx *EventContext) ([]Message, error) { return nil, nil })
+	huge := `{"event":{"surfaceId":"s","data":{"text":"` + strings.Repeat("x", DefaultMaxBodySize) + `"}}}`
+	if rec := postEvent(t, r, huge); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 over the default limit, got %d", rec.Code)
+	}
+}
+
+func TestEventRouterServeHTTPProtocol(t *testing.T) {
+	r := NewEventRouter().SetProtocol(ProtocolV09)
+	r.HandleFunc("", "", "", func(ctx *EventContext) ([]Message, error) {
+		return []Message{{BeginRendering: &BeginRendering{SurfaceID: "s", Root: "root"}}}, nil
+	})
+
+	rec := postEvent(t, r, `{"event":{"surfaceId":"s"}}`)
+	if !strings.HasPrefix(rec.Body.String(), `{"createSurface":`) {
+		t.Errorf("expected v0.9 output, got %q", rec.Body.String())
+	}
+}
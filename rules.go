@@ -0,0 +1,238 @@
+package a2ui
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ruleChecker collects property errors for one component.
+type ruleChecker struct {
+	c    *Component
+	errs []ValidationError
+}
+
+func (r *ruleChecker) add(severity Severity, field, format string, args ...any) {
+	r.errs = append(r.errs, ValidationError{
+		ComponentID: r.c.ID,
+		Field:       r.c.Component + "." + field,
+		Message:     fmt.Sprintf(format, args...),
+		Severity:    severity,
+	})
+}
+
+func (r *ruleChecker) errorf(field, format string, args ...any) {
+	r.add(SeverityError, field, format, args...)
+}
+
+func (r *ruleChecker) warnf(field, format string, args ...any) {
+	r.add(SeverityWarning, field, format, args...)
+}
+
+// bound reports whether the component has a non-empty data binding.
+func (r *ruleChecker) bound() bool {
+	return r.c.DataBinding != nil && r.c.DataBinding.Path != ""
+}
+
+// oneOf reports value if it is set but not among allowed.
+func oneOf[T ~string](r *ruleChecker, field string, value T, allowed ...T) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	r.errorf(field, "invalid value '%s'", value)
+}
+
+// componentRules holds the property rules of each standard component type.
+// Child references are checked separately by Validate.
+var componentRules = map[string]func(r *ruleChecker){
+	"Column": layoutRules,
+	"Row":    layoutRules,
+
+	"Card": func(r *ruleChecker) {
+		if r.c.Child == "" {
+			r.errorf("Child", "child is required")
+		}
+	},
+
+	"Text": func(r *ruleChecker) {
+		if r.c.Text == "" && !r.bound() {
+			r.warnf("Text", "text is empty and not bound to data")
+		}
+		usageHintRule(r)
+	},
+
+	"Image": func(r *ruleChecker) {
+		if r.c.URL == "" && !r.bound() {
+			r.errorf("URL", "url is required unless bound to data")
+		}
+		oneOf(r, "Fit", r.c.Fit, ImageFitContain, ImageFitCover, ImageFitFill, ImageFitNone, ImageFitScaleDown)
+		usageHintRule(r)
+	},
+
+	"Icon": func(r *ruleChecker) {
+		if r.c.Icon == "" {
+			r.errorf("Icon", "icon is required")
+			return
+		}
+		for _, known := range knownIcons {
+			if r.c.Icon == known {
+				return
+			}
+		}
+		r.warnf("Icon", "unknown icon '%s'", r.c.Icon)
+	},
+
+	"Video": mediaRules,
+
+	"AudioPlayer": mediaRules,
+
+	"Divider": func(r *ruleChecker) {
+		oneOf(r, "Orientation", r.c.Orientation, "horizontal", "vertical")
+	},
+
+	"Button": func(r *ruleChecker) {
+		if r.c.Child == "" {
+			r.errorf("Child", "child is required")
+		}
+		if r.c.Action == nil {
+			r.errorf("Action", "action is required")
+		} else if r.c.Action.Type == "" {
+			r.errorf("Action.Type", "action type is required")
+		}
+	},
+
+	"TextField": func(r *ruleChecker) {
+		if r.c.Label == "" {
+			r.warnf("Label", "label is empty")
+		}
+		oneOf(r, "TextFieldType", r.c.TextFieldType, TextFieldTypeShortText, TextFieldTypeLongText,
+			TextFieldTypeNumber, TextFieldTypeDate, TextFieldTypeObscured)
+		if r.c.ValidationRegexp != "" {
+			if _, err := regexp.Compile(r.c.ValidationRegexp); err != nil {
+				r.errorf("ValidationRegexp", "invalid regular expression: %v", err)
+			}
+		}
+	},
+
+	"CheckBox": func(r *ruleChecker) {
+		if r.c.Label == "" {
+			r.warnf("Label", "label is empty")
+		}
+	},
+
+	"DateTimeInput": func(r *ruleChecker) {
+		if !r.c.EnableDate && !r.c.EnableTime {
+			r.errorf("EnableDate", "at least one of enableDate and enableTime must be true")
+		}
+	},
+
+	"MultipleChoice": func(r *ruleChecker) {
+		if len(r.c.Options) == 0 {
+			r.errorf("Options", "at least one option is required")
+		}
+		values := make(map[string]bool, len(r.c.Options))
+		for i, opt := range r.c.Options {
+			if values[opt.Value] {
+				r.errorf(fmt.Sprintf("Options[%d].Value", i), "duplicate option value '%s'", opt.Value)
+			}
+			values[opt.Value] = true
+		}
+		for i, sel := range r.c.Selections {
+			if !values[sel] {
+				r.errorf(fmt.Sprintf("Selections[%d]", i), "selection '%s' is not an option", sel)
+			}
+		}
+		if r.c.MaxAllowedSelections < 0 {
+			r.errorf("MaxAllowedSelections", "must not be negative")
+		} else if r.c.MaxAllowedSelections > 0 && len(r.c.Selections) > r.c.MaxAllowedSelections {
+			r.errorf("Selections", "%d selections exceed maxAllowedSelections %d",
+				len(r.c.Selections), r.c.MaxAllowedSelections)
+		}
+	},
+
+	"Slider": func(r *ruleChecker) {
+		if r.c.MinValue > r.c.MaxValue {
+			r.errorf("MinValue", "minValue %g is greater than maxValue %g", r.c.MinValue, r.c.MaxValue)
+			return
+		}
+		// A bound slider takes its value from the data model
+		if !r.bound() && (r.c.SliderValue < r.c.MinValue || r.c.SliderValue > r.c.MaxValue) {
+			r.errorf("SliderValue", "value %g is outside [%g, %g]", r.c.SliderValue, r.c.MinValue, r.c.MaxValue)
+		}
+	},
+
+	"List": func(r *ruleChecker) {
+		if !r.bound() {
+			r.errorf("DataBinding", "data binding is required")
+		}
+		if r.c.Template == "" {
+			r.errorf("Template", "template is required")
+		}
+		oneOf(r, "Direction", r.c.Direction, "vertical", "horizontal")
+	},
+
+	"Tabs": func(r *ruleChecker) {
+		if len(r.c.Tabs) == 0 {
+			r.errorf("Tabs", "at least one tab is required")
+		}
+		for i, tab := range r.c.Tabs {
+			if tab.Title == "" {
+				r.warnf(fmt.Sprintf("Tabs[%d].Title", i), "title is empty")
+			}
+		}
+	},
+
+	"Modal": func(r *ruleChecker) {
+		if r.c.EntryPointChild == "" {
+			r.errorf("EntryPointChild", "entryPointChild is required")
+		}
+		if r.c.ContentChild == "" {
+			r.errorf("ContentChild", "contentChild is required")
+		}
+	},
+}
+
+var knownIcons = []IconName{
+	IconAccountCircle, IconAdd, IconArrowBack, IconCheck, IconClose, IconDelete, IconEdit,
+	IconFavorite, IconHome, IconMenu, IconSearch, IconSettings, IconStar, IconWarning,
+}
+
+func layoutRules(r *ruleChecker) {
+	oneOf(r, "Distribution", r.c.Distribution, DistributionStart, DistributionCenter, DistributionEnd,
+		DistributionSpaceAround, DistributionSpaceBetween, DistributionSpaceEvenly)
+	oneOf(r, "Alignment", r.c.Alignment, AlignmentStart, AlignmentCenter, AlignmentEnd, AlignmentStretch)
+
+	seen := make(map[string]bool, len(r.c.Children))
+	for i, child := range r.c.Children {
+		if seen[child] {
+			r.warnf(fmt.Sprintf("Children[%d]", i), "child '%s' is listed more than once", child)
+		}
+		seen[child] = true
+	}
+}
+
+func mediaRules(r *ruleChecker) {
+	if r.c.URL == "" && !r.bound() {
+		r.errorf("URL", "url is required unless bound to data")
+	}
+}
+
+func usageHintRule(r *ruleChecker) {
+	oneOf(r, "UsageHint", r.c.UsageHint, UsageHintH1, UsageHintH2, UsageHintH3, UsageHintH4,
+		UsageHintH5, UsageHintBody, UsageHintCaption)
+}
+
+// propertyErrors applies the rules for c's component type.
+func propertyErrors(c *Component) []ValidationError {
+	rule, ok := componentRules[c.Component]
+	if !ok {
+		return nil
+	}
+	r := &ruleChecker{c: c}
+	rule(r)
+	return r.errs
+}
@@ -0,0 +1,104 @@
+package a2ui
+
+import (
+	"testing"
+)
+
+func TestPropertyRulesHelpersAreValid(t *testing.T) {
+	components := []Component{
+		Column("col", "a"), Row("row", "a"), Card("card", "a"),
+		TextStatic("text", "Hi"), TextBound("textb", "/t"),
+		ImageStatic("img", "https://example.com/a.png", "A"), ImageBound("imgb", "/img", "A"),
+		ButtonOnly("btn", "a", "submit"),
+		TextField("tf", "Name", ""), TextFieldBound("tfb", "Name", "", "/n"),
+		ListTemplate("list", "a", "/items"),
+		Tabs("tabs", Tab("One", "a")), Modal("modal", "a", "b"),
+		Icon("icon", IconHome), Video("video", "v.mp4"), VideoBound("videob", "/v"),
+		AudioPlayer("audio", "a.mp3", "Song"), AudioPlayerBound("audiob", "/a", "Song"),
+		Divider("div"), DividerVertical("divv"),
+		CheckBox("cb", "Agree", true), CheckBoxBound("cbb", "Agree", "/agree"),
+		DateTimeInput("dt", "When", true, false), DateTimeInputBound("dtb", "When", "/when", false, true),
+		MultipleChoice("mc", "Pick", []ChoiceOption{Choice("A", "a")}),
+		MultipleChoiceBound("mcb", "Pick", "/pick", []ChoiceOption{Choice("A", "a")}),
+		Slider("slider", "Volume", 0, 10, 5), SliderBound("sliderb", "Volume", "/vol", 1, 10),
+		TextWithHint("hint", "Title", UsageHintH1),
+		ImageWithFit("fit", "a.png", "A", ImageFitCover),
+		TextFieldWithType("tft", "Age", "", TextFieldTypeNumber),
+		ColumnWithLayout("coll", DistributionCenter, AlignmentStretch, "a"),
+		RowWithLayout("rowl", DistributionSpaceBetween, AlignmentEnd, "a"),
+	}
+	components = append(components, Button("button", "Go", "submit")...)
+
+	for _, c := range components {
+		if errs := propertyErrors(&c); len(errs) != 0 {
+			t.Errorf("%s: expected no property errors, got %v", c.ID, errs)
+		}
+	}
+}
+
+func TestPropertyRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		component Component
+		field     string
+		severity  Severity
+	}{
+		{"SliderMinAboveMax", Slider("x", "", 10, 1, 5), "Slider.MinValue", SeverityError},
+		{"SliderValueOutOfRange", Slider("x", "", 0, 10, 11), "Slider.SliderValue", SeverityError},
+		{"SelectionNotAnOption", Component{ID: "x", Component: "MultipleChoice",
+			Options: []ChoiceOption{Choice("A", "a")}, Selections: []string{"a", "z"}}, "MultipleChoice.Selections[1]", SeverityError},
+		{"TooManySelections", Component{ID: "x", Component: "MultipleChoice", MaxAllowedSelections: 1,
+			Options: []ChoiceOption{Choice("A", "a"), Choice("B", "b")}, Selections: []string{"a", "b"}}, "MultipleChoice.Selections", SeverityError},
+		{"DuplicateOption", MultipleChoice("x", "", []ChoiceOption{Choice("A", "a"), Choice("B", "a")}), "MultipleChoice.Options[1].Value", SeverityError},
+		{"NoOptions", MultipleChoice("x", "", nil), "MultipleChoice.Options", SeverityError},
+		{"BadRegexp", Component{ID: "x", Component: "TextField", Label: "L", ValidationRegexp: "[a-"}, "TextField.ValidationRegexp", SeverityError},
+		{"BadTextFieldType", TextFieldWithType("x", "L", "", "color"), "TextField.TextFieldType", SeverityError},
+		{"DateTimeNothingEnabled", DateTimeInput("x", "", false, false), "DateTimeInput.EnableDate", SeverityError},
+		{"ImageNoURL", ImageStatic("x", "", "alt"), "Image.URL", SeverityError},
+		{"ImageBadFit", ImageWithFit("x", "a.png", "", "stretch"), "Image.Fit", SeverityError},
+		{"VideoNoURL", Video("x", ""), "Video.URL", SeverityError},
+		{"AudioNoURL", AudioPlayer("x", "", ""), "AudioPlayer.URL", SeverityError},
+		{"ListNoBinding", Component{ID: "x", Component: "List", Template: "t"}, "List.DataBinding", SeverityError},
+		{"ListNoTemplate", ListTemplate("x", "", "/items"), "List.Template", SeverityError},
+		{"ListBadDirection", Component{ID: "x", Component: "List", Template: "t", DataBinding: &DataBinding{Path: "/i"}, Direction: "diagonal"}, "List.Direction", SeverityError},
+		{"ButtonNoAction", Component{ID: "x", Component: "Button", Child: "c"}, "Button.Action", SeverityError},
+		{"ButtonEmptyActionType", ButtonOnly("x", "c", ""), "Button.Action.Type", SeverityError},
+		{"ButtonNoChild", ButtonOnly("x", "", "submit"), "Button.Child", SeverityError},
+		{"CardNoChild", Card("x", ""), "Card.Child", SeverityError},
+		{"ModalNoContent", Modal("x", "a", ""), "Modal.ContentChild", SeverityError},
+		{"TabsEmpty", Tabs("x"), "Tabs.Tabs", SeverityError},
+		{"TabWithoutTitle", Tabs("x", Tab("", "a")), "Tabs.Tabs[0].Title", SeverityWarning},
+		{"IconMissing", Icon("x", ""), "Icon.Icon", SeverityError},
+		{"IconUnknown", Icon("x", "rocket"), "Icon.Icon", SeverityWarning},
+		{"DividerBadOrientation", Component{ID: "x", Component: "Divider", Orientation: "diagonal"}, "Divider.Orientation", SeverityError},
+		{"TextEmpty", TextStatic("x", ""), "Text.Text", SeverityWarning},
+		{"TextBadHint", TextWithHint("x", "T", "h7"), "Text.UsageHint", SeverityError},
+		{"CheckBoxNoLabel", CheckBox("x", "", false), "CheckBox.Label", SeverityWarning},
+		{"ColumnBadAlignment", ColumnWithLayout("x", "", "middle", "a"), "Column.Alignment", SeverityError},
+		{"RowBadDistribution", RowWithLayout("x", "spread", "", "a"), "Row.Distribution", SeverityError},
+		{"RepeatedChild", Column("x", "a", "a"), "Column.Children[1]", SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := propertyErrors(&tt.component)
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %v", errs)
+			}
+			if errs[0].Field != tt.field || errs[0].Severity != tt.severity || errs[0].ComponentID != "x" {
+				t.Errorf("expected %s %s, got %+v", tt.severity, tt.field, errs[0])
+			}
+		})
+	}
+}
+
+func TestValidateReportsPropertyErrors(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "slider"))
+	s.Add(Slider("slider", "Volume", 0, 10, 20))
+
+	errs := s.Validate()
+	if len(errs) != 1 || errs[0].Field != "Slider.SliderValue" {
+		t.Errorf("expected slider range error, got %v", errs)
+	}
+}
@@ -0,0 +1,291 @@
+package a2ui
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaDialect is the JSON Schema dialect of the schemas returned by JSONSchema.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema for the messages of DefaultRegistry's
+// components. See Registry.JSONSchema.
+func JSONSchema() map[string]any {
+	return DefaultRegistry.JSONSchema()
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the messages
+// this package emits in the default protocol, for use with structured
+// output and tool calling APIs. The result marshals with encoding/json.
+//
+// The root schema is a Message. Its "$defs" hold every payload type, the
+// enum types and "Component", a oneOf of one definition per registered
+// component type (named "<Type>Component") discriminated by a constant
+// "component" property. Standard components allow only the properties
+// their type uses; custom components allow the properties of their Go
+// type, including those of the embedded Component.
+func (r *Registry) JSONSchema() map[string]any {
+	b := &schemaBuilder{defs: make(map[string]any)}
+
+	var variants []any
+	for _, name := range r.Names() {
+		def := name + "Component"
+		t, _ := r.Lookup(name) // names are never unregistered
+		b.defs[def] = b.componentSchema(name, t)
+		variants = append(variants, schemaRef(def))
+	}
+	b.defs["Component"] = map[string]any{"oneOf": variants}
+
+	b.typeSchema(reflect.TypeOf(Message{}))
+	message := b.defs["Message"].(map[string]any)
+	message["minProperties"] = 1
+	message["maxProperties"] = 1
+
+	return map[string]any{
+		"$schema": SchemaDialect,
+		"$ref":    "#/$defs/Message",
+		"$defs":   b.defs,
+	}
+}
+
+// componentSpec lists the JSON properties a standard component type uses
+// besides id and component, and which of them are required.
+type componentSpec struct {
+	properties []string
+	required   []string
+}
+
+var componentSpecs = map[string]componentSpec{
+	"Column":         {[]string{"children", "distribution", "alignment"}, nil},
+	"Row":            {[]string{"children", "distribution", "alignment"}, nil},
+	"Card":           {[]string{"child"}, []string{"child"}},
+	"List":           {[]string{"template", "dataBinding", "direction"}, []string{"template", "dataBinding"}},
+	"Tabs":           {[]string{"tabs"}, []string{"tabs"}},
+	"Modal":          {[]string{"entryPointChild", "contentChild"}, []string{"entryPointChild", "contentChild"}},
+	"Text":           {[]string{"text", "usageHint", "dataBinding"}, nil},
+	"Image":          {[]string{"url", "alt", "fit", "usageHint", "dataBinding"}, nil},
+	"Icon":           {[]string{"icon"}, []string{"icon"}},
+	"Video":          {[]string{"url", "dataBinding"}, nil},
+	"AudioPlayer":    {[]string{"url", "description", "dataBinding"}, nil},
+	"Divider":        {[]string{"orientation"}, nil},
+	"Button":         {[]string{"child", "action", "primary"}, []string{"child", "action"}},
+	"TextField":      {[]string{"label", "placeholder", "text", "textFieldType", "validationRegexp", "dataBinding"}, nil},
+	"CheckBox":       {[]string{"label", "checked", "dataBinding"}, nil},
+	"DateTimeInput":  {[]string{"label", "enableDate", "enableTime", "dataBinding"}, nil},
+	"MultipleChoice": {[]string{"label", "options", "selections", "maxAllowedSelections", "dataBinding"}, []string{"options"}},
+	"Slider":         {[]string{"label", "minValue", "maxValue", "value", "dataBinding"}, nil},
+}
+
+// enumValues lists the allowed values of each string enum type.
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(Distribution("")): enumStrings(DistributionStart, DistributionCenter, DistributionEnd,
+		DistributionSpaceAround, DistributionSpaceBetween, DistributionSpaceEvenly),
+	reflect.TypeOf(Alignment("")): enumStrings(AlignmentStart, AlignmentCenter, AlignmentEnd, AlignmentStretch),
+	reflect.TypeOf(UsageHint("")): enumStrings(UsageHintH1, UsageHintH2, UsageHintH3, UsageHintH4,
+		UsageHintH5, UsageHintBody, UsageHintCaption),
+	reflect.TypeOf(ImageFit("")): enumStrings(ImageFitContain, ImageFitCover, ImageFitFill, ImageFitNone,
+		ImageFitScaleDown),
+	reflect.TypeOf(IconName("")): enumStrings(knownIcons...),
+	reflect.TypeOf(TextFieldType("")): enumStrings(TextFieldTypeShortText, TextFieldTypeLongText,
+		TextFieldTypeNumber, TextFieldTypeDate, TextFieldTypeObscured),
+}
+
+// schemaOverrides replaces the reflected schema of fields, keyed by
+// "<Go type>.<JSON property>".
+var schemaOverrides = map[string]map[string]any{
+	"Component.direction":         {"type": "string", "enum": []string{"vertical", "horizontal"}},
+	"Component.orientation":       {"type": "string", "enum": []string{"horizontal", "vertical"}},
+	"UpdateComponents.components": {"type": "array", "items": schemaRef("Component")},
+	"PatchOp.op": {"type": "string", "enum": []string{PatchAdd, PatchRemove, PatchReplace,
+		PatchMove, PatchCopy, PatchTest}},
+}
+
+func enumStrings[T ~string](values ...T) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = string(v)
+	}
+	return s
+}
+
+func schemaRef(def string) map[string]any {
+	return map[string]any{"$ref": "#/$defs/" + def}
+}
+
+// schemaBuilder derives schemas from Go types, collecting named struct and
+// enum types in defs.
+type schemaBuilder struct {
+	defs map[string]any
+}
+
+// componentSchema returns the definition of the component type name
+// registered as t.
+func (b *schemaBuilder) componentSchema(name string, t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+	if spec, ok := componentSpecs[name]; ok && t == componentType {
+		all := make(map[string]any)
+		b.addFields(componentType, all, nil)
+		for _, p := range spec.properties {
+			props[p] = all[p]
+		}
+		required = spec.required
+	} else {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		b.addFields(t, props, &required)
+	}
+
+	props["id"] = map[string]any{"type": "string"}
+	props["component"] = map[string]any{"const": name}
+	req := []string{"id", "component"}
+	for _, p := range required {
+		if p != "id" && p != "component" {
+			req = append(req, p)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             req,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the schema of t, adding definitions as needed.
+func (b *schemaBuilder) typeSchema(t reflect.Type) map[string]any {
+	if values, ok := enumValues[t]; ok {
+		if _, ok := b.defs[t.Name()]; !ok {
+			b.defs[t.Name()] = map[string]any{"type": "string", "enum": values}
+		}
+		return schemaRef(t.Name())
+	}
+	switch t {
+	case componentType:
+		return schemaRef("Component")
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as base64
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.defs[t.Name()]; !ok {
+			b.defs[t.Name()] = nil // reserves the name for recursive types
+			b.defs[t.Name()] = b.structSchema(t)
+		}
+		return schemaRef(t.Name())
+	}
+	// Interfaces accept any value
+	return map[string]any{}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+	b.addFields(t, props, &required)
+	s := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// addFields adds the JSON properties of struct type t to props. Properties
+// without omitempty are appended to required if it is not nil.
+func (b *schemaBuilder) addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for _, f := range jsonFields(t) {
+		if override, ok := schemaOverrides[f.key()]; ok {
+			props[f.name] = override
+		} else {
+			props[f.name] = b.typeSchema(f.typ)
+		}
+		if required != nil && !f.omitempty && f.typ.Kind() != reflect.Pointer {
+			*required = append(*required, f.name)
+		}
+	}
+}
+
+// jsonField is a property of a struct type as encoding/json sees it.
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool
+	owner     reflect.Type // the struct declaring the field
+}
+
+// key returns the field's schemaOverrides key.
+func (f jsonField) key() string {
+	return f.owner.Name() + "." + f.name
+}
+
+// enum returns the allowed values of an enum field, or nil.
+func (f jsonField) enum() []string {
+	if values, ok := enumValues[f.typ]; ok {
+		return values
+	}
+	values, _ := schemaOverrides[f.key()]["enum"].([]string)
+	return values
+}
+
+// jsonFields returns the JSON properties of struct type t, following
+// encoding/json's rules for tags and embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(ft)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			typ:       f.Type,
+			omitempty: strings.Contains(","+opts+",", ",omitempty,"),
+			owner:     t,
+		})
+	}
+	return fields
+}
@@ -0,0 +1,138 @@
+package a2ui
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// schemaDefs marshals and decodes the schema so tests see plain JSON values.
+func schemaDefs(t *testing.T, schema map[string]any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["$schema"] != SchemaDialect || decoded["$ref"] != "#/$defs/Message" {
+		t.Errorf("unexpected root: %v", decoded)
+	}
+	return decoded["$defs"].(map[string]any)
+}
+
+// checkRefs reports $refs that do not resolve to a definition.
+func checkRefs(t *testing.T, defs map[string]any, node any) {
+	t.Helper()
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			if _, ok := defs[strings.TrimPrefix(ref, "#/$defs/")]; !ok {
+				t.Errorf("unresolved $ref %s", ref)
+			}
+		}
+		for _, v := range n {
+			checkRefs(t, defs, v)
+		}
+	case []any:
+		for _, v := range n {
+			checkRefs(t, defs, v)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	defs := schemaDefs(t, JSONSchema())
+	checkRefs(t, defs, defs)
+
+	message := defs["Message"].(map[string]any)
+	if message["maxProperties"] != float64(1) {
+		t.Errorf("expected a message to have exactly one payload, got %v", message)
+	}
+	for _, key := range []string{"beginRendering", "updateComponents", "dataModelUpdate", "deleteSurface", "dataModelPatch"} {
+		if _, ok := message["properties"].(map[string]any)[key]; !ok {
+			t.Errorf("expected message property %s", key)
+		}
+	}
+
+	if variants := defs["Component"].(map[string]any)["oneOf"].([]any); len(variants) != len(StandardComponents) {
+		t.Errorf("expected one variant per standard component, got %d", len(variants))
+	}
+
+	wantEnums := map[string]int{"Distribution": 6, "Alignment": 4, "UsageHint": 7, "ImageFit": 5, "IconName": 14, "TextFieldType": 5}
+	for name, n := range wantEnums {
+		def, _ := defs[name].(map[string]any)
+		if values, _ := def["enum"].([]any); len(values) != n {
+			t.Errorf("expected %d values for %s, got %v", n, name, def)
+		}
+	}
+
+	card := defs["CardComponent"].(map[string]any)
+	if card["properties"].(map[string]any)["component"].(map[string]any)["const"] != "Card" {
+		t.Errorf("expected component discriminator, got %v", card)
+	}
+	if !reflect.DeepEqual(card["required"], []any{"id", "component", "child"}) {
+		t.Errorf("unexpected required properties: %v", card["required"])
+	}
+}
+
+func TestJSONSchemaCoversHelpers(t *testing.T) {
+	defs := schemaDefs(t, JSONSchema())
+	components := []Component{
+		ColumnWithLayout("col", DistributionCenter, AlignmentStretch, "a"), Card("card", "a"),
+		TextWithHint("text", "Hi", UsageHintH1), TextBound("textb", "/t"),
+		ImageWithFit("img", "a.png", "A", ImageFitCover), ImageBound("imgb", "/img", "A"),
+		ButtonOnly("btn", "a", "submit"), TextFieldWithType("tf", "Age", "0", TextFieldTypeNumber),
+		TextFieldBound("tfb", "Name", "", "/n"), ListTemplate("list", "a", "/items"),
+		Tabs("tabs", Tab("One", "a")), Modal("modal", "a", "b"), Icon("icon", IconHome),
+		VideoBound("video", "/v"), AudioPlayer("audio", "a.mp3", "Song"), DividerVertical("div"),
+		CheckBoxBound("cb", "Agree", "/agree"), DateTimeInputBound("dt", "When", "/when", true, true),
+		MultipleChoiceBound("mc", "Pick", "/pick", []ChoiceOption{Choice("A", "a")}),
+		SliderBound("slider", "Volume", "/vol", 1, 10),
+	}
+
+	for _, c := range components {
+		data, _ := json.Marshal(c)
+		var fields map[string]any
+		json.Unmarshal(data, &fields)
+		props := defs[c.Component+"Component"].(map[string]any)["properties"].(map[string]any)
+		for key := range fields {
+			if _, ok := props[key]; !ok {
+				t.Errorf("%s: property %s is not in the schema", c.Component, key)
+			}
+		}
+	}
+}
+
+func TestJSONSchemaCustomComponents(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("Gauge", Gauge{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("Sparkline", &SparklineChart{}); err != nil {
+		t.Fatal(err)
+	}
+	defs := schemaDefs(t, r.JSONSchema())
+	checkRefs(t, defs, defs)
+
+	gauge := defs["GaugeComponent"].(map[string]any)
+	props := gauge["properties"].(map[string]any)
+	if props["thresholds"].(map[string]any)["items"].(map[string]any)["type"] != "integer" {
+		t.Errorf("unexpected thresholds schema: %v", props["thresholds"])
+	}
+	if props["child"] == nil || props["distribution"].(map[string]any)["$ref"] != "#/$defs/Distribution" {
+		t.Errorf("expected embedded Component properties, got %v", props)
+	}
+	if !reflect.DeepEqual(gauge["required"], []any{"id", "component", "color", "showLabel"}) {
+		t.Errorf("unexpected required properties: %v", gauge["required"])
+	}
+	if props := defs["SparklineComponent"].(map[string]any)["properties"].(map[string]any); props["data"] == nil {
+		t.Errorf("expected pointer-registered component properties, got %v", props)
+	}
+	if variants := defs["Component"].(map[string]any)["oneOf"].([]any); len(variants) != len(StandardComponents)+2 {
+		t.Errorf("expected custom components in the Component oneOf, got %d", len(variants))
+	}
+}
@@ -0,0 +1,309 @@
+package a2ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SSEWriter writes messages as Server-Sent Events (text/event-stream).
+// Each message line becomes one event and is flushed immediately.
+//
+// SSEWriter also implements io.Writer, treating every complete line as an
+// event, so existing code can switch transports by passing it to WriteJSONL
+// or WriteMessage instead of the http.ResponseWriter.
+type SSEWriter struct {
+	mu         sync.Mutex
+	w          http.ResponseWriter
+	flusher    http.Flusher
+	version    ProtocolVersion
+	eventNames bool
+	ids        bool
+	lastID     uint64
+	replay     *ReplayBuffer
+	partial    []byte
+}
+
+// NewSSEWriter creates an SSE writer and sets the event stream headers on w.
+// Headers must not have been written yet.
+func NewSSEWriter(w http.ResponseWriter) *SSEWriter {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+
+	flusher, _ := w.(http.Flusher)
+	return &SSEWriter{w: w, flusher: flusher}
+}
+
+// SetProtocol sets the protocol version used by WriteMessage.
+func (s *SSEWriter) SetProtocol(v ProtocolVersion) *SSEWriter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = v
+	return s
+}
+
+// SetEventNames enables "event:" fields named after the message kind
+// (for example "beginRendering"), so clients can use addEventListener per kind.
+func (s *SSEWriter) SetEventNames(enabled bool) *SSEWriter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventNames = enabled
+	return s
+}
+
+// SetIDs enables monotonically increasing "id:" fields starting at 1, which
+// browsers send back as Last-Event-ID when reconnecting.
+func (s *SSEWriter) SetIDs(enabled bool) *SSEWriter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = enabled
+	return s
+}
+
+// SetReplay records every written message in b and uses its sequence
+// numbers as event IDs, so a reconnecting client can be resumed with
+// Resume. A message that spans several events gets its ID on the last one.
+// Lines written through Write are decoded with DefaultRegistry to be
+// recorded, so register custom components or use WriteMessage.
+func (s *SSEWriter) SetReplay(b *ReplayBuffer) *SSEWriter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replay = b
+	return s
+}
+
+// LastID returns the ID of the last event written with IDs enabled.
+func (s *SSEWriter) LastID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastID
+}
+
+// WriteMessage writes msg as one or more events, depending on the protocol version.
+func (s *SSEWriter) WriteMessage(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines, err := EncodeMessage(msg, s.version)
+	if err != nil {
+		return err
+	}
+	if s.replay != nil {
+		return s.writeLines(lines, s.replay.Append(msg)[0].Seq)
+	}
+	for _, line := range lines {
+		if err := s.writeEvent(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resume writes what a client that last saw event lastID is missing: the
+// buffered messages after it, or a snapshot if they are gone (see
+// ReplayBuffer.Resume). It requires SetReplay; use LastEventID to read
+// lastID from the reconnect request.
+func (s *SSEWriter) Resume(lastID uint64, snapshot func() []Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.replay == nil {
+		return errors.New("a2ui: sse: Resume requires SetReplay")
+	}
+	return s.writeSequenced(s.replay.Resume(lastID, snapshot))
+}
+
+// writeSequenced writes recorded messages with their sequence numbers as
+// IDs. Callers must hold s.mu.
+func (s *SSEWriter) writeSequenced(entries []SequencedMessage) error {
+	for _, e := range entries {
+		lines, err := EncodeMessage(e.Message, s.version)
+		if err != nil {
+			return err
+		}
+		if err := s.writeLines(lines, e.Seq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLines writes the lines of one message, the last with seq as its
+// ID. LastID only advances once that event is written, so a message that
+// encodes to no lines leaves it unchanged. Callers must hold s.mu.
+func (s *SSEWriter) writeLines(lines [][]byte, seq uint64) error {
+	for i, line := range lines {
+		if i < len(lines)-1 {
+			if err := s.event(line, ""); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.event(line, strconv.FormatUint(seq, 10)); err != nil {
+			return err
+		}
+		s.lastID = seq
+	}
+	return nil
+}
+
+// WriteMessages writes all messages in order.
+func (s *SSEWriter) WriteMessages(messages []Message) error {
+	for _, msg := range messages {
+		if err := s.WriteMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer. Every complete non-empty line is sent as an
+// event; incomplete trailing data is buffered until its newline arrives.
+func (s *SSEWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.partial = append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(s.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := bytes.TrimSpace(s.partial[:i])
+		s.partial = s.partial[i+1:]
+		if len(line) == 0 {
+			continue
+		}
+		if s.replay != nil {
+			if err := s.writeRecorded(line); err != nil {
+				return len(p), err
+			}
+			continue
+		}
+		if err := s.writeEvent(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Comment writes an SSE comment line, ignored by clients.
+func (s *SSEWriter) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send([]byte(": " + text + "\n\n"))
+}
+
+// StartHeartbeat writes a heartbeat comment every interval to keep proxies
+// from closing an idle connection. It stops when the returned function is
+// called, ctx is done or a write fails. An interval of zero or less starts
+// no heartbeat.
+//
+// The heartbeat must stop before the handler returns, since the
+// ResponseWriter must not be used after that: pass the request context
+// and defer stop. stop waits until no heartbeat is being written.
+func (s *SSEWriter) StartHeartbeat(ctx context.Context, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if ctx.Err() != nil {
+					return
+				}
+				if err := s.Comment("heartbeat"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
+
+// writeRecorded records a line written through Write in the replay buffer
+// and writes it unchanged with its sequence number. Callers must hold s.mu.
+func (s *SSEWriter) writeRecorded(line []byte) error {
+	msg, err := decodeMessage(line, DefaultRegistry)
+	if err != nil {
+		return err
+	}
+	return s.writeLines([][]byte{line}, s.replay.Append(msg)[0].Seq)
+}
+
+// writeEvent writes one JSON line as an event, numbered if IDs are
+// enabled. Callers must hold s.mu.
+func (s *SSEWriter) writeEvent(line []byte) error {
+	id := ""
+	if s.ids {
+		s.lastID++
+		id = strconv.FormatUint(s.lastID, 10)
+	}
+	return s.event(line, id)
+}
+
+// event writes one JSON line as an event with the given ID, if any.
+// Callers must hold s.mu.
+func (s *SSEWriter) event(line []byte, id string) error {
+	var buf bytes.Buffer
+	if id != "" {
+		buf.WriteString("id: ")
+		buf.WriteString(id)
+		buf.WriteByte('\n')
+	}
+	if s.eventNames {
+		if name := eventName(line); name != "" {
+			buf.WriteString("event: ")
+			buf.WriteString(name)
+			buf.WriteByte('\n')
+		}
+	}
+	buf.WriteString("data: ")
+	buf.Write(line)
+	buf.WriteString("\n\n")
+	return s.send(buf.Bytes())
+}
+
+// send writes p and flushes. Callers must hold s.mu.
+func (s *SSEWriter) send(p []byte) error {
+	if _, err := s.w.Write(p); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+// eventName returns the single top-level key of a JSON message line.
+func eventName(line []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil || len(fields) != 1 {
+		return ""
+	}
+	for name := range fields {
+		return name
+	}
+	return ""
+}
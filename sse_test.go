@@ -0,0 +1,170 @@
+package a2ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSSEWriterHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewSSEWriter(rec)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got '%s'", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("expected no-cache, got '%s'", cc)
+	}
+}
+
+func TestSSEWriterWriteMessage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := NewSSEWriter(rec)
+
+	if err := sse.WriteMessage(Message{DeleteSurface: &DeleteSurface{SurfaceID: "s"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "data: {\"deleteSurface\":{\"surfaceId\":\"s\"}}\n\n"
+	if rec.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, rec.Body.String())
+	}
+	if !rec.Flushed {
+		t.Error("expected writer to flush")
+	}
+}
+
+func TestSSEWriterEventNamesAndIDs(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := NewSSEWriter(rec).SetEventNames(true).SetIDs(true)
+
+	s := NewSurface("s")
+	s.Add(TextStatic("root", "Hi"))
+	if err := sse.WriteMessages(s.Messages()); err != nil {
+		t.Fatal(err)
+	}
+
+	events := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %q", len(events), rec.Body.String())
+	}
+	if !strings.HasPrefix(events[0], "id: 1\nevent: beginRendering\ndata: {") {
+		t.Errorf("unexpected first event: %q", events[0])
+	}
+	if !strings.HasPrefix(events[1], "id: 2\nevent: updateComponents\ndata: {") {
+		t.Errorf("unexpected second event: %q", events[1])
+	}
+	if sse.LastID() != 2 {
+		t.Errorf("expected last ID 2, got %d", sse.LastID())
+	}
+}
+
+func TestSSEWriterProtocolNames(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := NewSSEWriter(rec).SetEventNames(true).SetProtocol(ProtocolV08)
+
+	s := NewSurface("s")
+	s.Add(TextStatic("root", "Hi"))
+	if err := sse.WriteMessage(s.UpdateComponentsMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "event: surfaceUpdate\n") {
+		t.Errorf("expected v0.8 event name, got %q", rec.Body.String())
+	}
+}
+
+func TestSSEWriterAsIOWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := NewSSEWriter(rec)
+
+	s := NewSurface("s")
+	s.Add(TextStatic("root", "Hi"))
+	if err := WriteJSONL(sse, s.Messages()); err != nil {
+		t.Fatal(err)
+	}
+
+	body := rec.Body.String()
+	if strings.Count(body, "data: ") != 2 {
+		t.Errorf("expected 2 events from WriteJSONL, got %q", body)
+	}
+
+	// Partial lines are buffered until complete
+	rec.Body.Reset()
+	sse.Write([]byte(`{"deleteSurface":`))
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected partial line to be buffered, got %q", rec.Body.String())
+	}
+	sse.Write([]byte(`{"surfaceId":"s"}}` + "\n"))
+	if rec.Body.String() != "data: {\"deleteSurface\":{\"surfaceId\":\"s\"}}\n\n" {
+		t.Errorf("unexpected event: %q", rec.Body.String())
+	}
+}
+
+// lockedRecorder is a ResponseWriter safe for concurrent use in tests.
+type lockedRecorder struct {
+	mu  sync.Mutex
+	rec *httptest.ResponseRecorder
+}
+
+func (l *lockedRecorder) Header() http.Header  { return l.rec.Header() }
+func (l *lockedRecorder) WriteHeader(code int) { l.rec.WriteHeader(code) }
+
+func (l *lockedRecorder) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rec.Write(p)
+}
+
+func (l *lockedRecorder) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rec.Body.String()
+}
+
+func TestSSEWriterHeartbeat(t *testing.T) {
+	rec := &lockedRecorder{rec: httptest.NewRecorder()}
+	sse := NewSSEWriter(rec)
+
+	stop := sse.StartHeartbeat(context.Background(), 5*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(rec.String(), ": heartbeat\n\n") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	stop() // safe to call twice
+
+	if !strings.Contains(rec.String(), ": heartbeat\n\n") {
+		t.Errorf("expected heartbeat comment, got %q", rec.String())
+	}
+}
+
+func TestSSEWriterHeartbeatStopsWithContext(t *testing.T) {
+	rec := &lockedRecorder{rec: httptest.NewRecorder()}
+	sse := NewSSEWriter(rec)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The handler returns without calling stop; its context ends
+	sse.StartHeartbeat(ctx, 5*time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond) // let a pending tick observe ctx
+	written := rec.String()
+	time.Sleep(30 * time.Millisecond)
+	if rec.String() != written {
+		t.Errorf("expected no heartbeat after the context ended, got %q", rec.String())
+	}
+}
+
+func TestSSEWriterHeartbeatInvalidInterval(t *testing.T) {
+	rec := &lockedRecorder{rec: httptest.NewRecorder()}
+	stop := NewSSEWriter(rec).StartHeartbeat(context.Background(), 0)
+	time.Sleep(10 * time.Millisecond)
+	stop()
+	if rec.String() != "" {
+		t.Errorf("expected no heartbeat, got %q", rec.String())
+	}
+}
@@ -0,0 +1,309 @@
+package a2ui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FlushPolicy controls when a Stream flushes written messages to the client.
+type FlushPolicy int
+
+const (
+	// FlushPerMessage flushes after every message.
+	FlushPerMessage FlushPolicy = iota
+	// FlushPerBatch flushes once per Send, Open or Update call.
+	FlushPerBatch
+	// FlushInterval flushes written messages periodically; see SetFlushInterval.
+	FlushInterval
+)
+
+// defaultFlushInterval is used by FlushInterval when no valid interval is set.
+const defaultFlushInterval = 100 * time.Millisecond
+
+var (
+	// ErrStreamClosed is returned by writes to a closed Stream.
+	ErrStreamClosed = errors.New("a2ui: stream closed")
+	// ErrFlushNotSupported is the error of a Stream whose ResponseWriter
+	// is not an http.Flusher, since its messages would not reach the
+	// client until the handler returns.
+	ErrFlushNotSupported = errors.New("a2ui: stream: response does not support flushing")
+)
+
+// Stream writes messages for one or more surfaces to an HTTP response as
+// JSON Lines (application/x-ndjson).
+//
+// The first write error, or the cancellation of the request context when
+// the client disconnects, is kept: later writes do nothing and return it,
+// so handlers can ignore individual results and check Err or Close once.
+// A Stream is safe for concurrent use.
+type Stream struct {
+	mu       sync.Mutex
+	w        http.ResponseWriter
+	flusher  http.Flusher
+	ctx      context.Context
+	cancel   context.CancelFunc
+	version  ProtocolVersion
+	policy   FlushPolicy
+	surfaces []*Surface
+	pending  bool // written but not flushed
+	err      error
+	stop     chan struct{}
+}
+
+// NewStream creates a stream writing to w and sets the response headers,
+// which must not have been written yet. The stream's context is derived
+// from r's, which the server cancels when the client disconnects.
+//
+// Call Close before the handler returns, typically with defer: w must not
+// be used after that, and Close is what stops the FlushInterval goroutine
+// for certain. If w is not an http.Flusher, the stream starts out failed
+// with ErrFlushNotSupported and writes nothing; check Err before
+// streaming to respond otherwise.
+func NewStream(w http.ResponseWriter, r *http.Request) *Stream {
+	h := w.Header()
+	h.Set("Content-Type", "application/x-ndjson")
+	h.Set("Cache-Control", "no-cache")
+
+	flusher, ok := w.(http.Flusher)
+	ctx, cancel := context.WithCancel(r.Context())
+	s := &Stream{w: w, flusher: flusher, ctx: ctx, cancel: cancel}
+	if !ok {
+		s.fail(ErrFlushNotSupported)
+	}
+	return s
+}
+
+// SetProtocol sets the protocol version messages are encoded for.
+func (s *Stream) SetProtocol(v ProtocolVersion) *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = v
+	return s
+}
+
+// SetFlushPolicy sets when written messages are flushed. The default is
+// FlushPerMessage. FlushInterval without SetFlushInterval flushes every
+// 100ms.
+func (s *Stream) SetFlushPolicy(p FlushPolicy) *Stream {
+	if p == FlushInterval {
+		return s.SetFlushInterval(defaultFlushInterval)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTicker()
+	s.policy = p
+	return s
+}
+
+// SetFlushInterval sets the FlushInterval policy: messages are flushed at
+// most every d, and on Flush and Close. A d of zero or less uses 100ms.
+func (s *Stream) SetFlushInterval(d time.Duration) *Stream {
+	if d <= 0 {
+		d = defaultFlushInterval
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTicker()
+	s.policy = FlushInterval
+	s.stop = make(chan struct{})
+	go s.tick(d, s.stop)
+	return s
+}
+
+func (s *Stream) tick(d time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.flushTick()
+		}
+	}
+}
+
+// flushTick flushes for the FlushInterval goroutine. It checks the
+// context under s.mu, so no tick touches w once the request has ended or
+// the stream was closed, even if the tick raced with either.
+func (s *Stream) flushTick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.check() != nil { // includes s.ctx.Err()
+		return
+	}
+	s.flush()
+}
+
+// stopTicker stops the FlushInterval goroutine. Callers must hold s.mu.
+func (s *Stream) stopTicker() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Context returns the stream's context. It is canceled when the client
+// disconnects, a write fails or the stream is closed.
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
+
+// Err returns the first error of the stream, or nil.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.check()
+}
+
+// check records a context error and returns the stream error. Callers
+// must hold s.mu.
+func (s *Stream) check() error {
+	if s.err == nil && s.ctx.Err() != nil {
+		s.err = s.ctx.Err()
+	}
+	return s.err
+}
+
+// fail records err as the stream error. Callers must hold s.mu.
+func (s *Stream) fail(err error) error {
+	if s.err == nil {
+		s.err = err
+		s.cancel()
+	}
+	return s.err
+}
+
+// Send writes messages as one batch.
+func (s *Stream) Send(messages ...Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send(messages)
+}
+
+// send writes messages and flushes according to the policy. Callers must
+// hold s.mu.
+func (s *Stream) send(messages []Message) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		lines, err := EncodeMessage(msg, s.version)
+		if err != nil {
+			return s.fail(err)
+		}
+		for _, line := range lines {
+			if _, err := s.w.Write(append(line, '\n')); err != nil {
+				return s.fail(err)
+			}
+		}
+		s.pending = true
+		if s.policy == FlushPerMessage {
+			s.flush()
+		}
+	}
+	if s.policy == FlushPerBatch {
+		s.flush()
+	}
+	return nil
+}
+
+// flush flushes written messages. Callers must hold s.mu.
+func (s *Stream) flush() {
+	if s.pending {
+		s.flusher.Flush()
+	}
+	s.pending = false
+}
+
+// Flush flushes written messages now, regardless of the policy.
+func (s *Stream) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+// Open starts streaming surf: it sends surf.Messages() and includes surf in
+// later Update calls. Opening a surface again resends it in full.
+func (s *Stream) Open(surf *Surface) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(surf.ID())
+	s.surfaces = append(s.surfaces, surf)
+	return s.send(surf.Messages())
+}
+
+// Surface returns the open surface with the given ID.
+func (s *Stream) Surface(id string) (*Surface, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, surf := range s.surfaces {
+		if surf.ID() == id {
+			return surf, true
+		}
+	}
+	return nil, false
+}
+
+// Update sends the pending changes of all open surfaces, in the order they
+// were opened, as one batch.
+func (s *Stream) Update() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []Message
+	for _, surf := range s.surfaces {
+		messages = append(messages, surf.Flush()...)
+	}
+	if len(messages) == 0 {
+		return s.check()
+	}
+	return s.send(messages)
+}
+
+// Delete sends a DeleteSurface message for id and stops updating the
+// surface if it is open.
+func (s *Stream) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(id)
+	return s.send([]Message{{DeleteSurface: &DeleteSurface{SurfaceID: id}}})
+}
+
+// remove stops tracking the surface id. Callers must hold s.mu.
+func (s *Stream) remove(id string) {
+	for i, surf := range s.surfaces {
+		if surf.ID() == id {
+			s.surfaces = append(s.surfaces[:i], s.surfaces[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close flushes written messages, stops the stream and returns its first
+// error other than ErrStreamClosed. Writes after Close return
+// ErrStreamClosed. Close does not close the response; return from the
+// handler to end it.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTicker()
+	err := s.check()
+	if err == nil {
+		s.flush()
+		s.err = ErrStreamClosed
+	}
+	s.cancel()
+	if err == ErrStreamClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package a2ui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flushRecorder counts flushes and can fail writes.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	mu      sync.Mutex
+	flushes int
+	fail    error
+}
+
+func newFlushRecorder() *flushRecorder {
+	return &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+}
+
+func (r *flushRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		return 0, r.fail
+	}
+	return r.ResponseRecorder.Write(p)
+}
+
+func (r *flushRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes++
+}
+
+func (r *flushRecorder) flushCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushes
+}
+
+func TestStreamSurfaces(t *testing.T) {
+	rec := newFlushRecorder()
+	st := NewStream(rec, httptest.NewRequest(http.MethodGet, "/", nil)).SetFlushPolicy(FlushPerBatch)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	main := NewSurface("main").Add(TextStatic("root", "Hello"))
+	side := NewSurface("side").Add(TextStatic("root", "Side"))
+	st.Open(main)
+	st.Open(side)
+	if rec.flushCount() != 2 {
+		t.Errorf("expected one flush per batch, got %d", rec.flushCount())
+	}
+
+	main.Add(TextStatic("root", "Hi"))
+	side.SetData("/x", 1)
+	st.Update()
+	st.Update() // nothing pending
+	st.Delete("side")
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := ReadJSONL(strings.NewReader(rec.Body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "main:beginRendering main:updateComponents side:beginRendering side:updateComponents " +
+		"main:updateComponents side:dataModelUpdate side:deleteSurface"
+	if got := messageKinds(msgs); got != want {
+		t.Errorf("unexpected messages:\nwant %s\ngot  %s", want, got)
+	}
+	if _, ok := st.Surface("side"); ok {
+		t.Error("expected deleted surface to be closed")
+	}
+	if err := st.Send(msgs[0]); err != ErrStreamClosed {
+		t.Errorf("expected ErrStreamClosed after Close, got %v", err)
+	}
+}
+
+func TestStreamFlushPolicies(t *testing.T) {
+	msgs := NewSurface("s").Add(TextStatic("root", "Hi")).Messages()
+
+	rec := newFlushRecorder()
+	NewStream(rec, httptest.NewRequest(http.MethodGet, "/", nil)).Send(msgs...)
+	if rec.flushCount() != len(msgs) {
+		t.Errorf("expected a flush per message, got %d", rec.flushCount())
+	}
+
+	rec = newFlushRecorder()
+	st := NewStream(rec, httptest.NewRequest(http.MethodGet, "/", nil)).SetFlushInterval(10 * time.Millisecond)
+	st.Send(msgs...)
+	if rec.flushCount() != 0 {
+		t.Errorf("expected no immediate flush, got %d", rec.flushCount())
+	}
+	deadline := time.Now().Add(time.Second)
+	for rec.flushCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rec.flushCount() != 1 {
+		t.Errorf("expected one timed flush, got %d", rec.flushCount())
+	}
+	st.Close()
+
+	// A non-positive interval falls back to the default instead of panicking
+	rec = newFlushRecorder()
+	st = NewStream(rec, httptest.NewRequest(http.MethodGet, "/", nil)).SetFlushInterval(0)
+	st.Send(msgs...)
+	deadline = time.Now().Add(time.Second)
+	for rec.flushCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rec.flushCount() != 1 {
+		t.Errorf("expected one timed flush with the default interval, got %d", rec.flushCount())
+	}
+	st.Close()
+}
+
+func TestStreamIntervalWithoutClose(t *testing.T) {
+	rec := newFlushRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	// The handler returns without Close; net/http then cancels the context
+	handler := func() {
+		st := NewStream(rec, req).SetFlushInterval(20 * time.Millisecond)
+		st.Send(Message{DeleteSurface: &DeleteSurface{SurfaceID: "s"}})
+	}
+	handler()
+	cancel()
+
+	time.Sleep(80 * time.Millisecond)
+	if rec.flushCount() != 0 {
+		t.Errorf("expected no flush after the request ended, got %d", rec.flushCount())
+	}
+}
+
+func TestStreamWithoutFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := struct{ http.ResponseWriter }{rec} // hides Flush
+	st := NewStream(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if err := st.Err(); err != ErrFlushNotSupported {
+		t.Fatalf("expected ErrFlushNotSupported, got %v", err)
+	}
+	if err := st.Send(Message{DeleteSurface: &DeleteSurface{SurfaceID: "s"}}); err != ErrFlushNotSupported {
+		t.Errorf("expected ErrFlushNotSupported from Send, got %v", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Error("expected nothing written")
+	}
+	if err := st.Close(); err != ErrFlushNotSupported {
+		t.Errorf("expected Close to report ErrFlushNotSupported, got %v", err)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	rec := newFlushRecorder()
+	rec.fail = errors.New("broken pipe")
+	st := NewStream(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	msg := Message{DeleteSurface: &DeleteSurface{SurfaceID: "s"}}
+
+	if err := st.Send(msg); err != rec.fail {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if st.Context().Err() == nil {
+		t.Error("expected context canceled after a write error")
+	}
+	rec.fail = nil
+	if err := st.Send(msg); err == nil || err.Error() != "broken pipe" {
+		t.Errorf("expected the first error again, got %v", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Error("expected no writes after an error")
+	}
+	if err := st.Close(); err == nil || err.Error() != "broken pipe" {
+		t.Errorf("expected Close to report the write error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	st = NewStream(newFlushRecorder(), req)
+	cancel()
+	if err := st.Send(msg); err != context.Canceled {
+		t.Errorf("expected context.Canceled after disconnect, got %v", err)
+	}
+
+	st = NewStream(newFlushRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)).SetProtocol(ProtocolV09)
+	if err := st.Send(Message{DataModelPatch: &DataModelPatch{SurfaceID: "s"}}); err == nil {
+		t.Error("expected encoding error for a patch under v0.9")
+	}
+}
@@ -0,0 +1,443 @@
+// Package tree builds A2UI surfaces from nested component trees.
+//
+// Children are passed as values instead of ID strings:
+//
+//	root := tree.Col(
+//		tree.Text("Welcome").Hint(a2ui.UsageHintH1),
+//		tree.Card(tree.Text("Body")),
+//		tree.Button(tree.Text("Go"), "submit").ID("go"),
+//	)
+//	surface, err := tree.Build("main", root)
+//
+// Build flattens the tree into a Surface's adjacency list. Components
+// without an explicit ID get deterministic IDs ("text-1", "card-1", ...)
+// in depth-first order, and every reference points to a component of the
+// tree, so the result has no dangling references.
+package tree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	a2ui "github.com/burka/a2ui-go"
+)
+
+// Node is a component whose children are nodes. Setters return the node
+// for chaining. A node may appear in several places of a tree; it is
+// emitted once and referenced by the same ID.
+type Node struct {
+	value    reflect.Value // the component; a2ui.Component or a custom struct
+	id       string
+	children []*Node
+	child    *Node
+	template *Node
+	tabs     []TabNode
+	entry    *Node
+	content  *Node
+	setters  []func(c *a2ui.Component)
+	err      error
+}
+
+// TabNode is a tab of a Tabs node.
+type TabNode struct {
+	Title string
+	Child *Node
+}
+
+func node(c a2ui.Component) *Node {
+	return &Node{value: reflect.ValueOf(c)}
+}
+
+// ID sets the node's component ID. Nodes without an ID get a generated one.
+func (n *Node) ID(id string) *Node {
+	n.id = id
+	return n
+}
+
+// Bind binds the component to a JSON Pointer path in the data model.
+func (n *Node) Bind(path string) *Node {
+	return n.Set(func(c *a2ui.Component) {
+		c.DataBinding = &a2ui.DataBinding{Path: path}
+	})
+}
+
+// Hint sets the usage hint of a Text or Image.
+func (n *Node) Hint(hint a2ui.UsageHint) *Node {
+	return n.Set(func(c *a2ui.Component) {
+		c.UsageHint = hint
+	})
+}
+
+// Layout sets the distribution and alignment of a Column or Row.
+func (n *Node) Layout(distribution a2ui.Distribution, alignment a2ui.Alignment) *Node {
+	return n.Set(func(c *a2ui.Component) {
+		c.Distribution = distribution
+		c.Alignment = alignment
+	})
+}
+
+// Set applies fn to the component when the tree is built, for properties
+// without a dedicated setter. Child references set by fn are replaced by
+// the node's own references, or cleared if it has none.
+func (n *Node) Set(fn func(c *a2ui.Component)) *Node {
+	n.setters = append(n.setters, fn)
+	return n
+}
+
+// Children appends child nodes, for Column, Row and custom containers.
+func (n *Node) Children(children ...*Node) *Node {
+	n.children = append(n.children, children...)
+	return n
+}
+
+// Child sets the single child, for Card, Button and custom containers.
+func (n *Node) Child(child *Node) *Node {
+	n.child = child
+	return n
+}
+
+// Col creates a vertical layout.
+func Col(children ...*Node) *Node {
+	return node(a2ui.Component{Component: "Column"}).Children(children...)
+}
+
+// Row creates a horizontal layout.
+func Row(children ...*Node) *Node {
+	return node(a2ui.Component{Component: "Row"}).Children(children...)
+}
+
+// Card creates a card container.
+func Card(child *Node) *Node {
+	return node(a2ui.Component{Component: "Card"}).Child(child)
+}
+
+// List creates a list that renders template for each item of the array at
+// path. Bindings inside the template are relative to the item.
+func List(path string, template *Node) *Node {
+	n := node(a2ui.Component{Component: "List", DataBinding: &a2ui.DataBinding{Path: path}})
+	n.template = template
+	return n
+}
+
+// Tabs creates a tabs container.
+func Tabs(tabs ...TabNode) *Node {
+	n := node(a2ui.Component{Component: "Tabs"})
+	n.tabs = tabs
+	return n
+}
+
+// Tab creates a tab for Tabs.
+func Tab(title string, child *Node) TabNode {
+	return TabNode{Title: title, Child: child}
+}
+
+// Modal creates a modal opened by entryPoint that shows content.
+func Modal(entryPoint, content *Node) *Node {
+	n := node(a2ui.Component{Component: "Modal"})
+	n.entry = entryPoint
+	n.content = content
+	return n
+}
+
+// Text creates a text component. Use Bind for bound text.
+func Text(text string) *Node {
+	return node(a2ui.TextStatic("", text))
+}
+
+// Image creates an image component.
+func Image(url, alt string) *Node {
+	return node(a2ui.ImageStatic("", url, alt))
+}
+
+// Icon creates an icon component.
+func Icon(icon a2ui.IconName) *Node {
+	return node(a2ui.Icon("", icon))
+}
+
+// Video creates a video component.
+func Video(url string) *Node {
+	return node(a2ui.Video("", url))
+}
+
+// AudioPlayer creates an audio player component.
+func AudioPlayer(url, description string) *Node {
+	return node(a2ui.AudioPlayer("", url, description))
+}
+
+// Divider creates a horizontal divider.
+func Divider() *Node {
+	return node(a2ui.Divider(""))
+}
+
+// Button creates a button showing child that sends actionType when clicked.
+func Button(child *Node, actionType string) *Node {
+	return node(a2ui.ButtonOnly("", "", actionType)).Child(child)
+}
+
+// TextField creates a text input field.
+func TextField(label, placeholder string) *Node {
+	return node(a2ui.TextField("", label, placeholder))
+}
+
+// CheckBox creates a checkbox.
+func CheckBox(label string, checked bool) *Node {
+	return node(a2ui.CheckBox("", label, checked))
+}
+
+// DateTimeInput creates a date and/or time picker.
+func DateTimeInput(label string, enableDate, enableTime bool) *Node {
+	return node(a2ui.DateTimeInput("", label, enableDate, enableTime))
+}
+
+// MultipleChoice creates a selection component.
+func MultipleChoice(label string, options ...a2ui.ChoiceOption) *Node {
+	return node(a2ui.MultipleChoice("", label, options))
+}
+
+// Slider creates a slider for a number in [min, max].
+func Slider(label string, min, max, value float64) *Node {
+	return node(a2ui.Slider("", label, min, max, value))
+}
+
+// Custom wraps a custom component: a struct, or pointer to a struct, that
+// embeds a2ui.Component, or an a2ui.Component. Its ID, if set, is used as the node's ID; add
+// children with Children or Child; child references already set on the
+// component are cleared. The component is copied by Custom, so later
+// changes to a struct passed by pointer do not affect the node.
+func Custom(c any) *Node {
+	v := reflect.ValueOf(c)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || !embedsComponent(v.Type()) {
+		return &Node{err: fmt.Errorf("a2ui: tree: %T does not embed a2ui.Component", c)}
+	}
+	v = reflect.ValueOf(v.Interface()) // copy, unaliasing pointer input
+	return &Node{value: v, id: v.FieldByName("ID").String()}
+}
+
+var componentType = reflect.TypeOf(a2ui.Component{})
+
+func embedsComponent(t reflect.Type) bool {
+	if t == componentType {
+		return true
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := t.FieldByName("Component")
+	return ok && f.Anonymous && f.Type == componentType && len(f.Index) == 1
+}
+
+// component returns the a2ui.Component of the addressable value v.
+func component(v reflect.Value) *a2ui.Component {
+	if v.Type() != componentType {
+		v = v.FieldByName("Component")
+	}
+	return v.Addr().Interface().(*a2ui.Component)
+}
+
+// name returns n's quoted explicit ID, or its type for error messages.
+func (n *Node) name() string {
+	if n.id != "" {
+		return "'" + n.id + "'"
+	}
+	return n.typeName()
+}
+
+// check reports a missing child the component type requires.
+func (n *Node) check() error {
+	switch n.typeName() {
+	case "Card", "Button":
+		if n.child == nil {
+			return fmt.Errorf("a2ui: tree: %s has nil child", n.name())
+		}
+	case "List":
+		if n.template == nil {
+			return fmt.Errorf("a2ui: tree: %s has nil template", n.name())
+		}
+	case "Modal":
+		if n.entry == nil || n.content == nil {
+			return fmt.Errorf("a2ui: tree: %s has nil entry point or content", n.name())
+		}
+	}
+	for _, tab := range n.tabs {
+		if tab.Child == nil {
+			return fmt.Errorf("a2ui: tree: tab '%s' of %s has nil child", tab.Title, n.name())
+		}
+	}
+	return nil
+}
+
+// typeName returns the component type of n, e.g. "Text".
+func (n *Node) typeName() string {
+	v := n.value
+	if v.Type() != componentType {
+		v = v.FieldByName("Component")
+	}
+	return v.FieldByName("Component").String()
+}
+
+// refs returns the nodes n refers to, in field order.
+func (n *Node) refs() []*Node {
+	var refs []*Node
+	add := func(nodes ...*Node) {
+		for _, r := range nodes {
+			if r != nil {
+				refs = append(refs, r)
+			}
+		}
+	}
+	add(n.children...)
+	add(n.child, n.template)
+	for _, tab := range n.tabs {
+		add(tab.Child)
+	}
+	add(n.entry, n.content)
+	return refs
+}
+
+// Build flattens root into a new surface. root becomes the surface root;
+// it gets the ID "root" unless it has an explicit ID.
+func Build(surfaceID string, root *Node) (*a2ui.Surface, error) {
+	rootID, components, err := Flatten(root)
+	if err != nil {
+		return nil, err
+	}
+	s := a2ui.NewSurface(surfaceID).SetRoot(rootID)
+	for _, c := range components {
+		s.Add(c)
+	}
+	return s, nil
+}
+
+// Flatten returns the components of the tree at root in depth-first order,
+// root first, along with root's ID. It fails if the tree contains a cycle,
+// two nodes with the same explicit ID, an invalid Custom node or a Card,
+// Button, Tab or Modal without its child. Nil children of Columns, Rows
+// and custom containers are skipped.
+func Flatten(root *Node) (string, []any, error) {
+	if root == nil {
+		return "", nil, fmt.Errorf("a2ui: tree: nil root")
+	}
+	f := &flattener{
+		explicit: make(map[string]bool),
+		ids:      make(map[*Node]string),
+		onPath:   make(map[*Node]bool),
+		counters: make(map[string]int),
+	}
+	if err := f.collect(root); err != nil {
+		return "", nil, err
+	}
+	if root.id == "" && !f.explicit["root"] {
+		f.ids[root] = "root"
+	}
+	for _, n := range f.order {
+		f.assign(n)
+	}
+
+	components := make([]any, len(f.order))
+	for i, n := range f.order {
+		components[i] = f.build(n)
+	}
+	return f.ids[root], components, nil
+}
+
+// flattener holds the state of one Flatten call.
+type flattener struct {
+	explicit map[string]bool
+	ids      map[*Node]string
+	onPath   map[*Node]bool
+	order    []*Node // distinct nodes in depth-first order
+	counters map[string]int
+}
+
+// collect checks the tree at n, recording its nodes and explicit IDs.
+func (f *flattener) collect(n *Node) error {
+	if n.err != nil {
+		return n.err
+	}
+	if f.onPath[n] {
+		return fmt.Errorf("a2ui: tree: cycle at %s", n.name())
+	}
+	if _, seen := f.ids[n]; seen {
+		return nil
+	}
+	if err := n.check(); err != nil {
+		return err
+	}
+	f.ids[n] = n.id
+	f.order = append(f.order, n)
+	if n.id != "" {
+		if f.explicit[n.id] {
+			return fmt.Errorf("a2ui: tree: duplicate id '%s'", n.id)
+		}
+		f.explicit[n.id] = true
+	}
+
+	f.onPath[n] = true
+	defer delete(f.onPath, n)
+	for _, r := range n.refs() {
+		if err := f.collect(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assign generates an ID for n unless it has one.
+func (f *flattener) assign(n *Node) {
+	if f.ids[n] != "" {
+		return
+	}
+	prefix := strings.ToLower(n.typeName())
+	if prefix == "" {
+		prefix = "component"
+	}
+	for {
+		f.counters[prefix]++
+		id := fmt.Sprintf("%s-%d", prefix, f.counters[prefix])
+		if !f.explicit[id] {
+			f.explicit[id] = true
+			f.ids[n] = id
+			return
+		}
+	}
+}
+
+// build returns a copy of n's component with its ID and references set.
+func (f *flattener) build(n *Node) any {
+	v := reflect.New(n.value.Type()).Elem()
+	v.Set(n.value)
+	c := component(v)
+	for _, set := range n.setters {
+		set(c)
+	}
+	c.ID = f.ids[n]
+
+	// References come only from nodes, so none can dangle
+	c.Children = nil
+	for _, child := range n.children {
+		if child != nil {
+			c.Children = append(c.Children, f.ids[child])
+		}
+	}
+	c.Child = f.ref(n.child)
+	c.Template = f.ref(n.template)
+	c.Tabs = nil
+	for _, tab := range n.tabs {
+		c.Tabs = append(c.Tabs, a2ui.TabDef{Title: tab.Title, Child: f.ids[tab.Child]})
+	}
+	c.EntryPointChild = f.ref(n.entry)
+	c.ContentChild = f.ref(n.content)
+	return v.Interface()
+}
+
+// ref returns the ID of n, or "" if n is nil.
+func (f *flattener) ref(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	return f.ids[n]
+}
@@ -0,0 +1,151 @@
+package tree
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	a2ui "github.com/burka/a2ui-go"
+)
+
+func ids(components []any) string {
+	var out []string
+	for _, c := range components {
+		switch v := c.(type) {
+		case a2ui.Component:
+			out = append(out, v.ID)
+		case gauge:
+			out = append(out, v.ID)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+type gauge struct {
+	a2ui.Component
+	Value float64 `json:"value"`
+}
+
+func TestBuild(t *testing.T) {
+	shared := Text("Shared")
+	root := Col(
+		Text("Welcome").Hint(a2ui.UsageHintH1),
+		Card(Col(Text("").Bind("/name"), shared)),
+		Button(Text("Go"), "submit").ID("go"),
+		nil,
+		Tabs(Tab("One", shared), Tab("Two", Divider())),
+		Modal(Button(Text("Open"), "open"), Text("Content").ID("text-3")),
+		List("/items", Card(Text("").Bind("/title"))),
+	).Layout(a2ui.DistributionCenter, a2ui.AlignmentStretch)
+
+	s, err := Build("main", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := s.Validate(); len(errs) != 0 {
+		t.Errorf("expected a valid surface, got %v", errs)
+	}
+
+	want := "root,text-1,card-1,column-1,text-2,text-4,go,text-5,tabs-1,divider-1,modal-1,button-1,text-6,text-3,list-1,card-2,text-7"
+	if got := ids(s.Components()); got != want {
+		t.Errorf("unexpected IDs:\nwant %s\ngot  %s", want, got)
+	}
+
+	c, _ := s.Get("root")
+	col := c.(a2ui.Component)
+	if col.Distribution != a2ui.DistributionCenter || !reflect.DeepEqual(col.Children, []string{"text-1", "card-1", "go", "tabs-1", "modal-1", "list-1"}) {
+		t.Errorf("unexpected root: %+v", col)
+	}
+	c, _ = s.Get("tabs-1")
+	if tabs := c.(a2ui.Component).Tabs; tabs[0].Child != "text-4" || tabs[1].Child != "divider-1" {
+		t.Errorf("expected shared node to keep its ID, got %+v", tabs)
+	}
+	c, _ = s.Get("text-2")
+	if c.(a2ui.Component).DataBinding.Path != "/name" {
+		t.Errorf("expected binding, got %+v", c)
+	}
+
+	// Building again yields the same IDs
+	again, _ := Build("main", root)
+	if ids(again.Components()) != want {
+		t.Error("expected deterministic IDs")
+	}
+}
+
+func TestBuildExplicitRoot(t *testing.T) {
+	s, err := Build("main", Card(Text("x").ID("root")).ID("page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Root() != "page" || ids(s.Components()) != "page,root" {
+		t.Errorf("unexpected root %s and IDs %s", s.Root(), ids(s.Components()))
+	}
+}
+
+func TestBuildCustom(t *testing.T) {
+	cpu := &gauge{Component: a2ui.Component{Component: "Gauge"}, Value: 0.5}
+	row := Row(
+		Custom(cpu).Child(Text("CPU")),
+		Custom(gauge{Component: a2ui.Component{ID: "mem", Component: "Gauge"}}),
+	)
+	cpu.Value = 0.9 // Custom took a copy
+	s, err := Build("dash", row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := s.Get("gauge-1")
+	g, ok := c.(gauge)
+	if !ok || g.Value != 0.5 || g.Child != "text-1" {
+		t.Errorf("unexpected custom component: %#v", c)
+	}
+	if _, ok := s.Get("mem"); !ok {
+		t.Error("expected the embedded ID to be kept")
+	}
+}
+
+func TestBuildClearsUnownedReferences(t *testing.T) {
+	s, err := Build("s", Col(
+		Col().Set(func(c *a2ui.Component) { c.Children = []string{"ghost"} }),
+		Custom(gauge{Component: a2ui.Component{Component: "Gauge", Child: "ghost2", Children: []string{"g3"}}}),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := s.Get("column-1"); c.(a2ui.Component).Children != nil {
+		t.Errorf("expected Set children to be cleared, got %#v", c)
+	}
+	if c, _ := s.Get("gauge-1"); c.(gauge).Child != "" || c.(gauge).Children != nil {
+		t.Errorf("expected custom references to be cleared, got %#v", c)
+	}
+	if errs := s.Validate(); len(errs) != 0 {
+		t.Errorf("expected a valid surface, got %v", errs)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	loop := Col()
+	loop.Children(Card(loop))
+
+	tests := []struct {
+		name string
+		root *Node
+		err  string
+	}{
+		{"Nil", nil, "a2ui: tree: nil root"},
+		{"Duplicate", Col(Text("a").ID("x"), Text("b").ID("x")), "a2ui: tree: duplicate id 'x'"},
+		{"Cycle", loop, "a2ui: tree: cycle at Column"},
+		{"BadCustom", Col(Custom(struct{ Name string }{})), "a2ui: tree: struct { Name string } does not embed a2ui.Component"},
+		{"NilCardChild", Col(Card(nil)), "a2ui: tree: Card has nil child"},
+		{"NilButtonChild", Button(nil, "go").ID("go"), "a2ui: tree: 'go' has nil child"},
+		{"NilTabChild", Tabs(Tab("A", Text("a")), Tab("B", nil)), "a2ui: tree: tab 'B' of Tabs has nil child"},
+		{"NilListTemplate", Col(List("/items", nil)), "a2ui: tree: List has nil template"},
+		{"NilModalContent", Modal(Text("open"), nil), "a2ui: tree: Modal has nil entry point or content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Build("s", tt.root); err == nil || err.Error() != tt.err {
+				t.Errorf("expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -4,12 +4,14 @@
 package a2ui
 
 // Message represents an A2UI protocol message.
-// Exactly one field should be set per message.
+// Exactly one field must be set per message; MarshalJSON and UnmarshalJSON
+// reject messages with no or multiple payloads. Use Kind to switch on the type.
 type Message struct {
 	BeginRendering   *BeginRendering   `json:"beginRendering,omitempty"`
 	UpdateComponents *UpdateComponents `json:"updateComponents,omitempty"`
 	DataModelUpdate  *DataModelUpdate  `json:"dataModelUpdate,omitempty"`
 	DeleteSurface    *DeleteSurface    `json:"deleteSurface,omitempty"`
+	DataModelPatch   *DataModelPatch   `json:"dataModelPatch,omitempty"`
 }
 
 // BeginRendering initializes a new UI surface.
@@ -31,6 +33,13 @@ type DataModelUpdate struct {
 	Contents  map[string]any `json:"contents"`
 }
 
+// DataModelPatch sends data model changes as JSON Patch operations.
+// It is only part of the default protocol.
+type DataModelPatch struct {
+	SurfaceID string    `json:"surfaceId"`
+	Patch     []PatchOp `json:"patch"`
+}
+
 // DeleteSurface removes a surface from the client.
 type DeleteSurface struct {
 	SurfaceID string `json:"surfaceId"`
@@ -0,0 +1,487 @@
+package a2ui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Severity classifies a ValidationError. The zero value is SeverityError.
+type Severity int
+
+const (
+	// SeverityError marks problems that prevent the surface from rendering correctly.
+	SeverityError Severity = iota
+	// SeverityWarning marks problems that render but are likely mistakes.
+	SeverityWarning
+)
+
+// String returns "error" or "warning".
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// ValidationError represents a validation error for a component.
+type ValidationError struct {
+	ComponentID string
+	Field       string
+	Message     string
+	Severity    Severity
+}
+
+func (e ValidationError) Error() string {
+	prefix := ""
+	if e.Severity == SeverityWarning {
+		prefix = "warning: "
+	}
+	if e.ComponentID != "" {
+		return fmt.Sprintf("%s%s: %s - %s", prefix, e.ComponentID, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s%s: %s", prefix, e.Field, e.Message)
+}
+
+// HasErrors reports whether errs contains anything more severe than a warning.
+func HasErrors(errs []ValidationError) bool {
+	for _, e := range errs {
+		if e.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator is implemented by custom component types that check their own
+// properties. Validate calls it for every component that implements it,
+// with either a value or a pointer receiver. Errors without a ComponentID
+// are attributed to the component.
+type Validator interface {
+	Validate() []ValidationError
+}
+
+// childRef is a reference from a component to another component ID.
+type childRef struct {
+	Field string // field name used in validation errors, e.g. "Card.Child"
+	Label string // noun used in messages, e.g. "child" or "template"
+	ID    string
+}
+
+// childRefs returns the component IDs c refers to, in field order.
+// Empty optional references are omitted; Tabs report every tab. Custom
+// component types refer to every non-empty child field they set.
+func childRefs(c *Component) []childRef {
+	var refs []childRef
+	optional := func(field, label, id string) {
+		if id != "" {
+			refs = append(refs, childRef{Field: field, Label: label, ID: id})
+		}
+	}
+
+	switch c.Component {
+	case "Column", "Row":
+		for _, child := range c.Children {
+			refs = append(refs, childRef{Field: c.Component + ".Children", Label: "child", ID: child})
+		}
+	case "Card":
+		optional("Card.Child", "child", c.Child)
+	case "Button":
+		optional("Button.Child", "child", c.Child)
+	case "List":
+		optional("List.Template", "template", c.Template)
+	case "Tabs":
+		for i, tab := range c.Tabs {
+			refs = append(refs, childRef{Field: fmt.Sprintf("Tabs.Tabs[%d].Child", i), Label: "child", ID: tab.Child})
+		}
+	case "Modal":
+		optional("Modal.EntryPointChild", "entryPointChild", c.EntryPointChild)
+		optional("Modal.ContentChild", "contentChild", c.ContentChild)
+	default:
+		if isStandardComponent(c.Component) {
+			break
+		}
+		for _, child := range c.Children {
+			refs = append(refs, childRef{Field: c.Component + ".Children", Label: "child", ID: child})
+		}
+		optional(c.Component+".Child", "child", c.Child)
+		optional(c.Component+".Template", "template", c.Template)
+		for i, tab := range c.Tabs {
+			optional(fmt.Sprintf("%s.Tabs[%d].Child", c.Component, i), "child", tab.Child)
+		}
+		optional(c.Component+".EntryPointChild", "entryPointChild", c.EntryPointChild)
+		optional(c.Component+".ContentChild", "contentChild", c.ContentChild)
+	}
+	return refs
+}
+
+func isStandardComponent(name string) bool {
+	for _, std := range StandardComponents {
+		if name == std {
+			return true
+		}
+	}
+	return false
+}
+
+// customErrors calls c's Validate method if it implements Validator,
+// attributing errors without a ComponentID to id.
+func customErrors(c any, id string) []ValidationError {
+	v, ok := c.(Validator)
+	if !ok {
+		// Pointer receivers are only reachable through an addressable copy
+		rv := reflect.ValueOf(c)
+		if rv.Kind() == reflect.Pointer {
+			return nil
+		}
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		if v, ok = ptr.Interface().(Validator); !ok {
+			return nil
+		}
+	}
+
+	errs := v.Validate()
+	for i := range errs {
+		if errs[i].ComponentID == "" {
+			errs[i].ComponentID = id
+		}
+	}
+	return errs
+}
+
+// Validate checks the surface for structural errors.
+// It returns a slice of validation errors (empty slice means valid).
+// Besides missing IDs and references it checks the properties of each
+// standard component type (for example a Slider's range or a List's data
+// binding), reports reference cycles as errors and components unreachable
+// from the root as warnings; use HasErrors to ignore warnings.
+// Custom component types are validated through their embedded Component
+// (or ComponentGetter) and, if they implement Validator, their own Validate.
+func (s *Surface) Validate() []ValidationError {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var errors []ValidationError
+
+	// Build a map of all component IDs
+	componentIDs := make(map[string]bool)
+	duplicates := make(map[string]bool)
+
+	// First pass: collect IDs and detect duplicates/empty IDs
+	for _, c := range s.components {
+		comp := asComponent(c)
+		if comp == nil {
+			errors = append(errors, ValidationError{
+				Field:   "Component",
+				Message: fmt.Sprintf("unsupported component type %T", c),
+			})
+			continue
+		}
+
+		if comp.ID == "" {
+			errors = append(errors, ValidationError{
+				ComponentID: "",
+				Field:       "ID",
+				Message:     "component ID must not be empty",
+			})
+			continue
+		}
+
+		if componentIDs[comp.ID] {
+			if !duplicates[comp.ID] {
+				errors = append(errors, ValidationError{
+					ComponentID: comp.ID,
+					Field:       "ID",
+					Message:     "duplicate component ID",
+				})
+				duplicates[comp.ID] = true
+			}
+		} else {
+			componentIDs[comp.ID] = true
+		}
+	}
+
+	// Check if root exists
+	if !componentIDs[s.root] {
+		errors = append(errors, ValidationError{
+			ComponentID: s.root,
+			Field:       "Root",
+			Message:     "root component not found",
+		})
+	}
+
+	// Second pass: check children references based on component type
+	for _, c := range s.components {
+		comp := asComponent(c)
+		if comp == nil {
+			continue
+		}
+
+		// Skip components with empty IDs (already reported)
+		if comp.ID == "" {
+			continue
+		}
+
+		if s.registry != nil && !s.registry.Known(comp.Component) {
+			errors = append(errors, ValidationError{
+				ComponentID: comp.ID,
+				Field:       "Component",
+				Message:     fmt.Sprintf("unknown component type '%s'", comp.Component),
+			})
+		}
+
+		errors = append(errors, propertyErrors(comp)...)
+		errors = append(errors, customErrors(c, comp.ID)...)
+
+		for _, ref := range childRefs(comp) {
+			if !componentIDs[ref.ID] {
+				errors = append(errors, ValidationError{
+					ComponentID: comp.ID,
+					Field:       ref.Field,
+					Message:     fmt.Sprintf("%s '%s' not found", ref.Label, ref.ID),
+				})
+			}
+		}
+	}
+
+	// Third pass: check the component graph
+	g := s.graph()
+	errors = append(errors, g.cycles()...)
+	if componentIDs[s.root] {
+		errors = append(errors, g.orphans(s.root)...)
+	}
+
+	return errors
+}
+
+// Depth returns the depth of the render tree: 1 for a root without
+// children, 0 if the root does not exist. It is the longest path from the
+// root that visits no component twice, so references that close a cycle
+// are not followed.
+func (s *Surface) Depth() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g := s.graph()
+	if _, ok := g.edges[s.root]; !ok {
+		return 0
+	}
+	return g.depth(s.root, make(map[string]bool), make(map[string]int), g.cyclic())
+}
+
+// componentGraph holds the references between the surface's components.
+type componentGraph struct {
+	order []string            // component IDs in surface order
+	edges map[string][]string // existing referenced IDs per component
+}
+
+func (s *Surface) graph() componentGraph {
+	g := componentGraph{edges: make(map[string][]string)}
+	for _, c := range s.components {
+		if comp := asComponent(c); comp != nil && comp.ID != "" {
+			if _, seen := g.edges[comp.ID]; !seen {
+				g.order = append(g.order, comp.ID)
+				g.edges[comp.ID] = nil
+			}
+		}
+	}
+	for _, c := range s.components {
+		comp := asComponent(c)
+		if comp == nil || comp.ID == "" {
+			continue
+		}
+		for _, ref := range childRefs(comp) {
+			if _, ok := g.edges[ref.ID]; ok {
+				g.edges[comp.ID] = append(g.edges[comp.ID], ref.ID)
+			}
+		}
+	}
+	return g
+}
+
+// maxCycles bounds the cycles cycles reports, since a densely connected
+// group of components can contain exponentially many.
+const maxCycles = 100
+
+// cycles reports every elementary reference cycle once, starting at the
+// component that comes first in surface order, up to maxCycles. It uses
+// Johnson's algorithm: for each component in order, it finds the cycles
+// through it among the components after it.
+func (g componentGraph) cycles() []ValidationError {
+	position := make(map[string]int, len(g.order))
+	for i, id := range g.order {
+		position[id] = i
+	}
+	reported := make(map[string]bool)
+
+	var errors []ValidationError
+	for i, start := range g.order {
+		blocked := make(map[string]bool)
+		blockers := make(map[string]map[string]bool)
+		var stack []string
+
+		var unblock func(id string)
+		unblock = func(id string) {
+			blocked[id] = false
+			for w := range blockers[id] {
+				delete(blockers[id], w)
+				if blocked[w] {
+					unblock(w)
+				}
+			}
+		}
+
+		var circuit func(id string) bool
+		circuit = func(id string) bool {
+			found := false
+			stack = append(stack, id)
+			blocked[id] = true
+			for _, next := range g.edges[id] {
+				if position[next] < i || len(errors) >= maxCycles {
+					continue
+				}
+				if next == start {
+					found = true
+					key := strings.Join(stack, "\x00")
+					if reported[key] {
+						continue
+					}
+					reported[key] = true
+					cycle := append(append([]string{}, stack...), start)
+					errors = append(errors, ValidationError{
+						ComponentID: start,
+						Field:       "Children",
+						Message:     "cycle: " + strings.Join(cycle, " -> "),
+					})
+				} else if !blocked[next] && circuit(next) {
+					found = true
+				}
+			}
+			if found {
+				unblock(id)
+			} else {
+				for _, next := range g.edges[id] {
+					if position[next] >= i {
+						if blockers[next] == nil {
+							blockers[next] = make(map[string]bool)
+						}
+						blockers[next][id] = true
+					}
+				}
+			}
+			stack = stack[:len(stack)-1]
+			return found
+		}
+		circuit(start)
+	}
+	return errors
+}
+
+// orphans reports components not reachable from root as warnings.
+func (g componentGraph) orphans(root string) []ValidationError {
+	reachable := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range g.edges[id] {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var errors []ValidationError
+	for _, id := range g.order {
+		if !reachable[id] {
+			errors = append(errors, ValidationError{
+				ComponentID: id,
+				Field:       "ID",
+				Message:     fmt.Sprintf("component is not reachable from root '%s'", root),
+				Severity:    SeverityWarning,
+			})
+		}
+	}
+	return errors
+}
+
+// depth returns the longest path length from id, skipping references to
+// components on the current path. Only components outside cycles are
+// memoized: the depth of a component on a cycle depends on the path it
+// is reached by.
+func (g componentGraph) depth(id string, onPath map[string]bool, memo map[string]int, cyclic map[string]bool) int {
+	if d, ok := memo[id]; ok {
+		return d
+	}
+	onPath[id] = true
+	max := 0
+	for _, next := range g.edges[id] {
+		if onPath[next] {
+			continue
+		}
+		if d := g.depth(next, onPath, memo, cyclic); d > max {
+			max = d
+		}
+	}
+	onPath[id] = false
+	if !cyclic[id] {
+		memo[id] = max + 1
+	}
+	return max + 1
+}
+
+// cyclic returns the components that lie on a reference cycle, found as
+// the strongly connected components with more than one member (Tarjan's
+// algorithm) and components that refer to themselves.
+func (g componentGraph) cyclic() map[string]bool {
+	cyclic := make(map[string]bool)
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		low[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, next := range g.edges[id] {
+			if next == id {
+				cyclic[id] = true
+			}
+			if _, seen := index[next]; !seen {
+				visit(next)
+				if low[next] < low[id] {
+					low[id] = low[next]
+				}
+			} else if onStack[next] && index[next] < low[id] {
+				low[id] = index[next]
+			}
+		}
+		if low[id] != index[id] {
+			return
+		}
+		var members []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			members = append(members, top)
+			if top == id {
+				break
+			}
+		}
+		if len(members) > 1 {
+			for _, member := range members {
+				cyclic[member] = true
+			}
+		}
+	}
+	for _, id := range g.order {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+	return cyclic
+}
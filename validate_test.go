@@ -0,0 +1,203 @@
+package a2ui
+
+import (
+	"testing"
+)
+
+func findValidationError(errs []ValidationError, componentID, message string) *ValidationError {
+	for i, e := range errs {
+		if e.ComponentID == componentID && e.Message == message {
+			return &errs[i]
+		}
+	}
+	return nil
+}
+
+func TestValidateCycleThroughCard(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "card"))
+	s.Add(Card("card", "inner"))
+	s.Add(Column("inner", "root"))
+
+	errs := s.Validate()
+	e := findValidationError(errs, "root", "cycle: root -> card -> inner -> root")
+	if e == nil {
+		t.Fatalf("expected cycle with full path, got %v", errs)
+	}
+	if e.Severity != SeverityError || e.Field != "Children" {
+		t.Errorf("expected cycle error on Children, got %+v", e)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the cycle to be reported once, got %v", errs)
+	}
+}
+
+func TestValidateCycleThroughTabsAndModal(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Tabs("root", TabDef{Title: "A", Child: "modal"}))
+	s.Add(Component{ID: "modal", Component: "Modal", EntryPointChild: "open", ContentChild: "root"})
+	s.Add(TextStatic("open", "Open"))
+
+	errs := s.Validate()
+	if findValidationError(errs, "root", "cycle: root -> modal -> root") == nil {
+		t.Errorf("expected Tabs/Modal cycle, got %v", errs)
+	}
+}
+
+func TestValidateCyclesSharingComponents(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "b", "c"))
+	s.Add(Card("b", "d"))
+	s.Add(Card("c", "d"))
+	s.Add(Column("d", "root"))
+
+	errs := s.Validate()
+	for _, cycle := range []string{"cycle: root -> b -> d -> root", "cycle: root -> c -> d -> root"} {
+		if findValidationError(errs, "root", cycle) == nil {
+			t.Errorf("expected %q, got %v", cycle, errs)
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected each cycle once, got %v", errs)
+	}
+}
+
+func TestValidateCyclesBounded(t *testing.T) {
+	// Every component refers to every other: far more than maxCycles cycles
+	ids := []string{"root", "a", "b", "c", "d", "e", "f", "g"}
+	s := NewSurface("test")
+	for _, id := range ids {
+		var children []string
+		for _, other := range ids {
+			if other != id {
+				children = append(children, other)
+			}
+		}
+		s.Add(Column(id, children...))
+	}
+	if n := len(s.Validate()); n != maxCycles {
+		t.Errorf("expected %d cycles, got %d", maxCycles, n)
+	}
+}
+
+func TestValidateSelfReference(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "root"))
+
+	if findValidationError(s.Validate(), "root", "cycle: root -> root") == nil {
+		t.Error("expected self-reference cycle")
+	}
+}
+
+func TestValidateOrphans(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "a"))
+	s.Add(TextStatic("a", "A"))
+	s.Add(TextStatic("stray", "Unused"))
+	s.Add(Column("loop1", "loop2"))
+	s.Add(Column("loop2", "loop1"))
+
+	errs := s.Validate()
+	orphan := findValidationError(errs, "stray", "component is not reachable from root 'root'")
+	if orphan == nil || orphan.Severity != SeverityWarning {
+		t.Fatalf("expected orphan warning, got %v", errs)
+	}
+	if orphan.Error() != "warning: stray: ID - component is not reachable from root 'root'" {
+		t.Errorf("unexpected warning format: %s", orphan.Error())
+	}
+	if findValidationError(errs, "loop1", "cycle: loop1 -> loop2 -> loop1") == nil {
+		t.Errorf("expected cycle among unreachable components, got %v", errs)
+	}
+	if !HasErrors(errs) {
+		t.Error("expected HasErrors for the cycle")
+	}
+
+	s.Remove("loop1")
+	s.Remove("loop2")
+	if errs := s.Validate(); HasErrors(errs) || len(errs) != 1 {
+		t.Errorf("expected only the orphan warning, got %v", errs)
+	}
+}
+
+func TestSurfaceDepth(t *testing.T) {
+	s := NewSurface("test")
+	if s.Depth() != 0 {
+		t.Errorf("expected depth 0 without root, got %d", s.Depth())
+	}
+
+	s.Add(Column("root", "header", "card"))
+	s.Add(TextStatic("header", "Title"))
+	s.Add(Card("card", "body"))
+	s.Add(Column("body", "text", "root"))
+	s.Add(TextStatic("text", "Hello"))
+
+	// root -> card -> body -> text; body -> root closes a cycle
+	if s.Depth() != 4 {
+		t.Errorf("expected depth 4, got %d", s.Depth())
+	}
+}
+
+func TestSurfaceDepthSharedNodeOnCycle(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "b", "a"))
+	s.Add(Column("a", "b"))
+	s.Add(Column("b", "a", "x"))
+	s.Add(Card("x", "y"))
+	s.Add(TextStatic("y", "Leaf"))
+
+	// a is first reached from b, where a -> b closes the cycle, but from
+	// root the path root -> a -> b -> x -> y is longest
+	if s.Depth() != 5 {
+		t.Errorf("expected depth 5, got %d", s.Depth())
+	}
+}
+
+// ratingWidget validates its own properties with a pointer receiver.
+type ratingWidget struct {
+	Component
+	Stars int `json:"stars"`
+}
+
+func (w *ratingWidget) Validate() []ValidationError {
+	if w.Stars < 1 || w.Stars > 5 {
+		return []ValidationError{{Field: "Rating.Stars", Message: "stars must be between 1 and 5"}}
+	}
+	return nil
+}
+
+// wrappedWidget holds its Component in a named field.
+type wrappedWidget struct {
+	Base Component `json:"-"`
+}
+
+func (w wrappedWidget) GetComponent() *Component { return &w.Base }
+
+func TestValidateCustomComponents(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Column("root", "gauge", "rating", "wrapped", "panel"))
+	s.Add(Gauge{Component: Component{ID: "gauge", Component: "Gauge"}, Color: "red"})
+	s.Add(ratingWidget{Component: Component{ID: "rating", Component: "Rating"}, Stars: 9})
+	s.Add(wrappedWidget{Base: Component{ID: "wrapped", Component: "Wrapped"}})
+	s.Add(&Gauge{Component: Component{ID: "panel", Component: "Panel", Children: []string{"missing"}}})
+
+	errs := s.Validate()
+	if e := findValidationError(errs, "rating", "stars must be between 1 and 5"); e == nil || e.Field != "Rating.Stars" {
+		t.Errorf("expected custom Validate error attributed to 'rating', got %v", errs)
+	}
+	if e := findValidationError(errs, "panel", "child 'missing' not found"); e == nil || e.Field != "Panel.Children" {
+		t.Errorf("expected custom container reference to be checked, got %v", errs)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected custom components to be found as children, got %v", errs)
+	}
+}
+
+func TestValidateCustomComponentCycle(t *testing.T) {
+	s := NewSurface("test")
+	s.Add(Gauge{Component: Component{ID: "root", Component: "Gauge", Child: "loop"}})
+	s.Add(Card("loop", "root"))
+
+	if findValidationError(s.Validate(), "root", "cycle: root -> loop -> root") == nil {
+		t.Error("expected cycle through custom component")
+	}
+}
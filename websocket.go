@@ -0,0 +1,571 @@
+package a2ui
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrSessionClosed is returned when using a session after it was closed.
+	ErrSessionClosed = errors.New("a2ui: session closed")
+	// ErrCloseTimeout is returned by Session.Close when the client does not
+	// reply to the close frame within CloseTimeout.
+	ErrCloseTimeout = errors.New("a2ui: websocket: close handshake timed out")
+)
+
+// websocketGUID is the fixed GUID from RFC 6455 used to compute Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes (RFC 6455 section 5.2).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// WebSocket close codes (RFC 6455 section 7.4.1).
+const (
+	closeNormal          = 1000
+	closeProtocolError   = 1002
+	closeUnsupportedData = 1003
+	closeInvalidPayload  = 1007
+	closeMessageTooBig   = 1009
+)
+
+// Upgrader configures WebSocket sessions. The zero value is usable and
+// applies the defaults documented on each field.
+type Upgrader struct {
+	// Protocol is the wire format of messages sent down the session.
+	Protocol ProtocolVersion
+
+	// SendQueue is the number of outgoing frames buffered before
+	// WriteMessage blocks (default 64).
+	SendQueue int
+
+	// ReceiveQueue is the number of decoded client messages buffered before
+	// the session stops reading from the connection (default 16).
+	ReceiveQueue int
+
+	// MaxMessageSize limits incoming messages in bytes (default 1 MiB).
+	MaxMessageSize int64
+
+	// PingInterval is how often pings are sent (default 30s).
+	PingInterval time.Duration
+
+	// PongTimeout is how long to wait beyond PingInterval for any frame from
+	// the client before the session fails (default 10s).
+	PongTimeout time.Duration
+
+	// WriteTimeout bounds each frame write (default 10s).
+	WriteTimeout time.Duration
+
+	// CloseTimeout is how long Close waits for the client's close reply (default 5s).
+	CloseTimeout time.Duration
+
+	// CheckOrigin reports whether the request origin is allowed.
+	// If nil, all origins are accepted.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// withDefaults returns a copy of u with zero fields set to defaults.
+func (u Upgrader) withDefaults() Upgrader {
+	if u.SendQueue <= 0 {
+		u.SendQueue = 64
+	}
+	if u.ReceiveQueue <= 0 {
+		u.ReceiveQueue = 16
+	}
+	if u.MaxMessageSize <= 0 {
+		u.MaxMessageSize = 1 << 20
+	}
+	if u.PingInterval <= 0 {
+		u.PingInterval = 30 * time.Second
+	}
+	if u.PongTimeout <= 0 {
+		u.PongTimeout = 10 * time.Second
+	}
+	if u.WriteTimeout <= 0 {
+		u.WriteTimeout = 10 * time.Second
+	}
+	if u.CloseTimeout <= 0 {
+		u.CloseTimeout = 5 * time.Second
+	}
+	return u
+}
+
+// Upgrade upgrades an HTTP request to a WebSocket session with default settings.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	var u Upgrader
+	return u.Upgrade(w, r)
+}
+
+// Upgrade performs the WebSocket handshake and starts the session.
+// On failure an HTTP error response has already been written.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	cfg := u.withDefaults()
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("a2ui: websocket: method %s not allowed", r.Method)
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, errors.New("a2ui: websocket: missing upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("a2ui: websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("a2ui: websocket: missing key")
+	}
+	if cfg.CheckOrigin != nil && !cfg.CheckOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, errors.New("a2ui: websocket: origin not allowed")
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("a2ui: websocket: response does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("a2ui: websocket: hijack: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("a2ui: websocket: handshake: %w", err)
+	}
+
+	s := &Session{
+		conn:     conn,
+		br:       rw.Reader,
+		cfg:      cfg,
+		send:     make(chan wsFrame, cfg.SendQueue),
+		incoming: make(chan ClientMessage, cfg.ReceiveQueue),
+		closing:  make(chan struct{}),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.readLoop()
+	go s.writeLoop()
+	return s, nil
+}
+
+// acceptKey computes Sec-WebSocket-Accept for a client key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerHasToken reports whether a comma-separated header contains token.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Session is a bidirectional WebSocket connection that carries Messages
+// from server to client and ClientMessages from client to server.
+// WriteMessage and Receive may be called from different goroutines.
+type Session struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	cfg      Upgrader
+	send     chan wsFrame
+	incoming chan ClientMessage
+
+	writeMu sync.Mutex // serializes frame writes
+
+	closing     chan struct{} // closed when Close starts
+	closingOnce sync.Once
+	stop        chan struct{} // closed to stop the write loop
+	stopOnce    sync.Once
+	stopped     chan struct{} // closed when the write loop returns
+	done        chan struct{} // closed when the connection is shut down
+	doneOnce    sync.Once
+
+	errMu sync.Mutex
+	err   error
+}
+
+type wsFrame struct {
+	op      byte
+	payload []byte
+}
+
+// WriteMessage queues msg to be sent as one text frame per encoded line.
+// It blocks while the send queue is full, providing backpressure to
+// producers that outpace the client.
+func (s *Session) WriteMessage(msg Message) error {
+	lines, err := EncodeMessage(msg, s.cfg.Protocol)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		select {
+		case <-s.closing:
+			return ErrSessionClosed
+		case <-s.done:
+			return s.closedErr()
+		default:
+		}
+		select {
+		case s.send <- wsFrame{op: opText, payload: line}:
+		case <-s.closing:
+			return ErrSessionClosed
+		case <-s.done:
+			return s.closedErr()
+		}
+	}
+	return nil
+}
+
+// WriteMessages queues all messages in order.
+func (s *Session) WriteMessages(messages []Message) error {
+	for _, msg := range messages {
+		if err := s.WriteMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive returns the next message sent by the client. It returns
+// ErrSessionClosed after a normal close, or the error that ended the session.
+func (s *Session) Receive() (ClientMessage, error) {
+	msg, ok := <-s.incoming
+	if !ok {
+		return ClientMessage{}, s.closedErr()
+	}
+	return msg, nil
+}
+
+// Done returns a channel that is closed when the session has ended.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the session, or nil after a normal close
+// or while the session is open.
+func (s *Session) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// Close sends queued messages, performs the closing handshake and closes the
+// connection. It waits at most CloseTimeout for the client's reply and
+// returns ErrCloseTimeout if none arrives. If the session has already
+// ended, it returns the error that ended it (see Err).
+func (s *Session) Close() error {
+	s.closingOnce.Do(func() { close(s.closing) })
+
+	select {
+	case s.send <- wsFrame{op: opClose, payload: closePayload(closeNormal, "")}:
+	case <-s.done:
+		return s.Err()
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(s.cfg.CloseTimeout):
+		s.shutdown(ErrCloseTimeout)
+	}
+	return s.Err()
+}
+
+func (s *Session) closedErr() error {
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return ErrSessionClosed
+}
+
+// shutdown closes the connection once, recording err as the session error.
+func (s *Session) shutdown(err error) {
+	s.doneOnce.Do(func() {
+		s.errMu.Lock()
+		s.err = err
+		s.errMu.Unlock()
+		s.conn.Close()
+		close(s.done)
+	})
+}
+
+// abort sends a close frame with code and shuts down with err.
+func (s *Session) abort(code int, err error) {
+	s.stopWriter()
+	s.writeFrame(opClose, closePayload(code, err.Error()))
+	s.shutdown(err)
+}
+
+// stopWriter stops the write loop and waits for it to return, so that no
+// frame follows a close frame sent by the read loop.
+func (s *Session) stopWriter() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.stopped
+}
+
+func (s *Session) writeLoop() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.stop:
+			return
+		case f := <-s.send:
+			if err := s.writeFrame(f.op, f.payload); err != nil {
+				s.shutdown(err)
+				return
+			}
+			if f.op == opClose {
+				// The read loop finishes the handshake when the reply arrives.
+				return
+			}
+		case <-ticker.C:
+			if err := s.writeFrame(opPing, nil); err != nil {
+				s.shutdown(err)
+				return
+			}
+		}
+	}
+}
+
+func (s *Session) readLoop() {
+	defer close(s.incoming)
+
+	var message []byte
+	var inMessage bool
+
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(s.cfg.PingInterval + s.cfg.PongTimeout))
+		fin, op, payload, err := s.readFrame()
+		if err != nil {
+			var perr *wsProtocolError
+			switch {
+			case errors.As(err, &perr):
+				s.abort(perr.code, err)
+			case isClosing(s.closing):
+				s.shutdown(nil)
+			default:
+				s.shutdown(err)
+			}
+			return
+		}
+
+		switch op {
+		case opPing:
+			if err := s.writeFrame(opPong, payload); err != nil {
+				s.shutdown(err)
+				return
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			if !isClosing(s.closing) {
+				code := closeNormal
+				if len(payload) >= 2 {
+					code = int(binary.BigEndian.Uint16(payload))
+				}
+				s.closingOnce.Do(func() { close(s.closing) })
+				s.stopWriter()
+				s.writeFrame(opClose, closePayload(code, ""))
+			}
+			s.shutdown(nil)
+			return
+		case opText:
+			if inMessage {
+				s.abort(closeProtocolError, errors.New("a2ui: websocket: new message before previous finished"))
+				return
+			}
+			message, inMessage = payload, true
+		case opContinuation:
+			if !inMessage {
+				s.abort(closeProtocolError, errors.New("a2ui: websocket: unexpected continuation frame"))
+				return
+			}
+			if int64(len(message)+len(payload)) > s.cfg.MaxMessageSize {
+				s.abort(closeMessageTooBig, errors.New("a2ui: websocket: message too big"))
+				return
+			}
+			message = append(message, payload...)
+		case opBinary:
+			s.abort(closeUnsupportedData, errors.New("a2ui: websocket: binary messages not supported"))
+			return
+		default:
+			s.abort(closeProtocolError, fmt.Errorf("a2ui: websocket: unknown opcode %#x", op))
+			return
+		}
+
+		if !fin {
+			continue
+		}
+		inMessage = false
+
+		if !utf8.Valid(message) {
+			s.abort(closeInvalidPayload, errors.New("a2ui: websocket: text message is not valid UTF-8"))
+			return
+		}
+		var msg ClientMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			s.abort(closeInvalidPayload, fmt.Errorf("a2ui: websocket: invalid client message: %w", err))
+			return
+		}
+		select {
+		case s.incoming <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func isClosing(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// wsProtocolError is a client protocol violation answered with a close code.
+type wsProtocolError struct {
+	code int
+	msg  string
+}
+
+func (e *wsProtocolError) Error() string {
+	return "a2ui: websocket: " + e.msg
+}
+
+// readFrame reads a single client frame and unmasks its payload.
+func (s *Session) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(s.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7f)
+
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, &wsProtocolError{closeProtocolError, "reserved bits set"}
+	}
+	if !masked {
+		return false, 0, nil, &wsProtocolError{closeProtocolError, "client frame not masked"}
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(s.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(s.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if op >= opClose && (!fin || length > 125) {
+		return false, 0, nil, &wsProtocolError{closeProtocolError, "invalid control frame"}
+	}
+	if length > uint64(s.cfg.MaxMessageSize) {
+		return false, 0, nil, &wsProtocolError{closeMessageTooBig, "message too big"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(s.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(s.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes a single unmasked, unfragmented server frame.
+func (s *Session) writeFrame(op byte, payload []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	buf := make([]byte, 0, len(payload)+10)
+	buf = append(buf, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, byte(n))
+	case n <= 0xffff:
+		buf = append(buf, 126, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, 127)
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		buf = append(buf, ext[:]...)
+	}
+	buf = append(buf, payload...)
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.cfg.WriteTimeout))
+	_, err := s.conn.Write(buf)
+	return err
+}
+
+// closePayload builds a close frame body, truncating reason to fit a
+// control frame without splitting a UTF-8 sequence.
+func closePayload(code int, reason string) []byte {
+	if len(reason) > 123 {
+		n := 123
+		for n > 0 && !utf8.RuneStart(reason[n]) {
+			n--
+		}
+		reason = reason[:n]
+	}
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	return append(p, reason...)
+}
@@ -0,0 +1,372 @@
+package a2ui
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsTestClient is a minimal WebSocket client for exercising Session.
+type wsTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// startWSServer starts a server that upgrades every request with u and
+// delivers the sessions on the returned channel.
+func startWSServer(t *testing.T, u *Upgrader) (*httptest.Server, chan *Session) {
+	t.Helper()
+	sessions := make(chan *Session, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := u.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		sessions <- s
+	}))
+	t.Cleanup(srv.Close)
+	return srv, sessions
+}
+
+func dialWS(t *testing.T, srv *httptest.Server) *wsTestClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	req := "GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: " + key + "\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	// Example from RFC 6455 section 1.3
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %s", got)
+	}
+	return &wsTestClient{t: t, conn: conn, br: br}
+}
+
+func (c *wsTestClient) writeFrame(fin bool, op byte, payload []byte, masked bool) {
+	c.t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	buf := []byte{b0}
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	default:
+		buf = append(buf, maskBit|126, byte(n>>8), byte(n))
+	}
+	data := append([]byte(nil), payload...)
+	if masked {
+		mask := []byte{1, 2, 3, 4}
+		buf = append(buf, mask...)
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	if _, err := c.conn.Write(append(buf, data...)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *wsTestClient) readFrame() (byte, []byte) {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	if head[1]&0x80 != 0 {
+		c.t.Fatal("server frames must not be masked")
+	}
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			c.t.Fatal(err)
+		}
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatal(err)
+	}
+	return head[0] & 0x0f, payload
+}
+
+func receiveSession(t *testing.T, sessions chan *Session) *Session {
+	t.Helper()
+	select {
+	case s := <-sessions:
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for session")
+		return nil
+	}
+}
+
+func TestWebSocketSendMessages(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+	defer s.Close()
+
+	surface := NewSurface("ws")
+	surface.Add(TextStatic("root", "Hi"))
+	if err := s.WriteMessages(surface.Messages()); err != nil {
+		t.Fatal(err)
+	}
+
+	op, payload := client.readFrame()
+	if op != opText || string(payload) != `{"beginRendering":{"surfaceId":"ws","root":"root"}}` {
+		t.Errorf("unexpected first frame: %x %s", op, payload)
+	}
+	op, payload = client.readFrame()
+	if op != opText || !strings.HasPrefix(string(payload), `{"updateComponents":`) {
+		t.Errorf("unexpected second frame: %x %s", op, payload)
+	}
+}
+
+func TestWebSocketReceiveClientMessage(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+	defer s.Close()
+
+	client.writeFrame(true, opText, []byte(`{"event":{"surfaceId":"f","componentId":"btn","type":"action","data":{"n":1}}}`), true)
+
+	msg, err := s.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Event == nil || msg.Event.ComponentID != "btn" || msg.Event.Data["n"] != float64(1) {
+		t.Errorf("unexpected client message: %+v", msg.Event)
+	}
+}
+
+func TestWebSocketFragmentsAndPing(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+	defer s.Close()
+
+	body := `{"event":{"surfaceId":"f","componentId":"x","type":"input"}}`
+	client.writeFrame(false, opText, []byte(body[:10]), true)
+	client.writeFrame(true, opPing, []byte("hi"), true)
+	client.writeFrame(true, opContinuation, []byte(body[10:]), true)
+
+	op, payload := client.readFrame()
+	if op != opPong || string(payload) != "hi" {
+		t.Errorf("expected pong 'hi', got %x %q", op, payload)
+	}
+
+	msg, err := s.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Event.ComponentID != "x" {
+		t.Errorf("expected reassembled message, got %+v", msg.Event)
+	}
+}
+
+func TestWebSocketServerPing(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{PingInterval: 10 * time.Millisecond, CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+	defer s.Close()
+
+	if op, _ := client.readFrame(); op != opPing {
+		t.Errorf("expected ping, got %x", op)
+	}
+}
+
+func TestWebSocketServerClose(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+
+	if err := s.WriteMessage(Message{DeleteSurface: &DeleteSurface{SurfaceID: "x"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+
+	// Queued messages are sent before the close frame
+	if op, _ := client.readFrame(); op != opText {
+		t.Errorf("expected queued text frame first, got %x", op)
+	}
+	op, payload := client.readFrame()
+	if op != opClose || binary.BigEndian.Uint16(payload) != closeNormal {
+		t.Fatalf("expected normal close frame, got %x %v", op, payload)
+	}
+	client.writeFrame(true, opClose, payload, true)
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return after reply")
+	}
+	if s.Err() != nil {
+		t.Errorf("expected no error after normal close, got %v", s.Err())
+	}
+	if _, err := s.Receive(); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from Receive, got %v", err)
+	}
+	if err := s.WriteMessage(Message{DeleteSurface: &DeleteSurface{SurfaceID: "x"}}); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from WriteMessage, got %v", err)
+	}
+}
+
+func TestWebSocketClientClose(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+
+	client.writeFrame(true, opClose, closePayload(closeNormal, "bye"), true)
+
+	op, payload := client.readFrame()
+	if op != opClose || binary.BigEndian.Uint16(payload) != closeNormal {
+		t.Errorf("expected echoed close frame, got %x %v", op, payload)
+	}
+	if _, err := s.Receive(); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed, got %v", err)
+	}
+}
+
+func TestWebSocketClientCloseStopsWrites(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+
+	go func() {
+		for s.WriteMessage(Message{DeleteSurface: &DeleteSurface{SurfaceID: "x"}}) == nil {
+		}
+	}()
+	if op, _ := client.readFrame(); op != opText {
+		t.Fatalf("expected text frame, got %x", op)
+	}
+	client.writeFrame(true, opClose, closePayload(closeNormal, ""), true)
+
+	for {
+		op, _ := client.readFrame()
+		if op == opClose {
+			break
+		}
+	}
+	<-s.Done()
+	client.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if n, err := client.br.Read(make([]byte, 1)); err == nil {
+		t.Errorf("expected no frames after the close reply, read %d bytes", n)
+	}
+}
+
+func TestWebSocketCloseTimeout(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{CloseTimeout: 20 * time.Millisecond})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+
+	// The client never replies to the close frame
+	if err := s.Close(); !errors.Is(err, ErrCloseTimeout) {
+		t.Errorf("expected ErrCloseTimeout, got %v", err)
+	}
+	if op, _ := client.readFrame(); op != opClose {
+		t.Errorf("expected close frame, got %x", op)
+	}
+	if err := s.Close(); !errors.Is(err, ErrCloseTimeout) {
+		t.Errorf("expected the ending error from a second Close, got %v", err)
+	}
+}
+
+func TestWebSocketInvalidUTF8(t *testing.T) {
+	srv, sessions := startWSServer(t, &Upgrader{})
+	client := dialWS(t, srv)
+	s := receiveSession(t, sessions)
+
+	// Fragmented so that the invalid sequence spans frames
+	client.writeFrame(false, opText, []byte("{\"userAction\":{\"name\":\"\xc3"), true)
+	client.writeFrame(true, opContinuation, []byte("\xff\"}}"), true)
+
+	op, payload := client.readFrame()
+	if op != opClose || binary.BigEndian.Uint16(payload) != closeInvalidPayload {
+		t.Errorf("expected close %d, got %x %v", closeInvalidPayload, op, payload)
+	}
+	<-s.Done()
+	if err := s.Err(); err == nil || !strings.Contains(err.Error(), "UTF-8") {
+		t.Errorf("expected UTF-8 error, got %v", err)
+	}
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(c *wsTestClient)
+		code uint16
+	}{
+		{"Unmasked", func(c *wsTestClient) { c.writeFrame(true, opText, []byte("{}"), false) }, closeProtocolError},
+		{"Binary", func(c *wsTestClient) { c.writeFrame(true, opBinary, []byte{1}, true) }, closeUnsupportedData},
+		{"InvalidJSON", func(c *wsTestClient) { c.writeFrame(true, opText, []byte("nope"), true) }, closeInvalidPayload},
+		{"TooBig", func(c *wsTestClient) { c.writeFrame(true, opText, make([]byte, 200), true) }, closeMessageTooBig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, sessions := startWSServer(t, &Upgrader{MaxMessageSize: 100})
+			client := dialWS(t, srv)
+			s := receiveSession(t, sessions)
+
+			tt.send(client)
+
+			op, payload := client.readFrame()
+			if op != opClose || binary.BigEndian.Uint16(payload) != tt.code {
+				t.Errorf("expected close %d, got %x %v", tt.code, op, payload)
+			}
+			<-s.Done()
+			if s.Err() == nil {
+				t.Error("expected session error")
+			}
+		})
+	}
+}
+
+func TestWebSocketRejectsPlainRequest(t *testing.T) {
+	srv, _ := startWSServer(t, &Upgrader{CloseTimeout: 50 * time.Millisecond})
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}